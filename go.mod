@@ -1,10 +1,16 @@
 module github.com/example/onepw-pdf-export
 
-go 1.20
+go 1.22
 
 require (
+	github.com/boombuler/barcode v1.0.1
 	github.com/jung-kurt/gofpdf v1.16.2
+	github.com/nbutton23/zxcvbn-go v0.0.0-20210217022336-fa2cb2858354
+	github.com/sethvargo/go-diceware v0.5.0
+	golang.org/x/crypto v0.26.0
+	golang.org/x/sys v0.23.0
 	golang.org/x/term v0.23.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require golang.org/x/sys v0.23.0 // indirect
+require github.com/ruudk/golang-pdf417 v0.0.0-20181029194003-1af4ab5afa58 // indirect
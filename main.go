@@ -2,51 +2,269 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base32"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/user"
 	"strings"
 	"time"
 	"path/filepath"
 
 	"golang.org/x/term"
 
+	"github.com/example/onepw-pdf-export/pkg/audit"
+	"github.com/example/onepw-pdf-export/pkg/diff"
 	"github.com/example/onepw-pdf-export/pkg/model"
 	"github.com/example/onepw-pdf-export/pkg/onepux"
 	"github.com/example/onepw-pdf-export/pkg/op"
+	"github.com/example/onepw-pdf-export/pkg/passphrase"
+	"github.com/example/onepw-pdf-export/pkg/pdfcrypt"
 	"github.com/example/onepw-pdf-export/pkg/pdfwriter"
+	"github.com/example/onepw-pdf-export/pkg/pwhash"
+	"github.com/example/onepw-pdf-export/pkg/query"
+	"github.com/example/onepw-pdf-export/pkg/secret"
+	"github.com/example/onepw-pdf-export/pkg/shamir"
+	"github.com/example/onepw-pdf-export/pkg/snapshot"
 )
 
 var version = "0.4.0"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "combine" {
+		runCombine(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		runVerify(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		runDiff(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		runAudit(os.Args[2:])
+		return
+	}
+
 	// Flags (can be omitted; we will prompt interactively)
 	var (
 		out          string
 		template     string
+		templateFile string
+		pageSize     string
+		orientation  string
+		margins      string
 		maskPw       bool
 		confirmRisk  bool
-		search       string
-		password     string
+		passwordFlag string
 		noInteractive bool
 		csvPath      string
 		onepuxPath   string
-		vaults       multiFlag
+		sortBy       string
+		groupBy      string
+		label        string
+		watermark    string
+		classification string
+		allow        string
+		ownerPwFlag  string
+		ownerPwFile  string
+		showOwnerPw  bool
+		recipientCerts multiFlag
+		shamirSpec   string
+		shareDir     string
+		genPassword  bool
+		minScore     int
+		allowWeak    bool
+		passwordFile string
+		passwordEnv  string
+		passwordFd   int
+		passwordOp   string
+		redactSpec   string
+		redactMode   string
+		redactKeep   int
+		outputMode   string
+		pwColors     bool
+		pwSpelling   bool
+		auditAppendix bool
+		hibpPath     string
+		totpSites    string
+		snapshotPath string
 	)
 
 	flag.StringVar(&out, "out", "", "Zieldatei (PDF)")
-	flag.StringVar(&template, "template", "", "Layout-Vorlage: compact|detailed (optional)")
-	flag.BoolVar(&maskPw, "mask-passwords", false, "Passwörter maskieren (optional)")
+	flag.StringVar(&template, "template", "", "Layout-Vorlage: compact|detailed|table|cards (optional)")
+	flag.StringVar(&templateFile, "template-file", "", "eigene Layout-Vorlage als YAML- oder JSON-Datei (statt --template)")
+	flag.StringVar(&pageSize, "page-size", "", "Seitenformat: A4|Letter|Legal|A5|A3 (Standard: aus der Vorlage)")
+	flag.StringVar(&orientation, "orientation", "", "Ausrichtung: portrait|landscape (Standard: aus der Vorlage)")
+	flag.StringVar(&margins, "margins", "", "Ränder in mm: 15 | oben/unten,links/rechts | oben,rechts,unten,links (Standard: aus der Vorlage)")
+	flag.BoolVar(&maskPw, "mask-passwords", false, "Passwörter maskieren (optional, wie --redact password:full; ein Modus aus --redact hat Vorrang)")
+	flag.StringVar(&redactSpec, "redact", "", "Felder schwärzen: password,totp,notes,concealed,cc|all, je optional mit :full|:partial|:hash")
+	flag.StringVar(&redactMode, "redact-mode", "full", "Standardmodus für --redact: full|partial|hash")
+	flag.IntVar(&redactKeep, "redact-keep", 2, "Sichtbare Zeichen am Anfang und Ende bei partial")
+	flag.StringVar(&outputMode, "output-mode", "plain", "Passwörter: plain (Klartext) | fingerprint (nur Fingerprint, Länge und Zeichenklassen)")
+	flag.BoolVar(&pwColors, "password-colors", false, "Geheimnisse nach Zeichenklassen färben: Ziffern, Sonderzeichen, Groß-/Kleinbuchstaben")
+	flag.BoolVar(&pwSpelling, "spell-passwords", false, "Unter jedem Passwort eine Zeile im NATO-Alphabet")
 	flag.BoolVar(&confirmRisk, "i-understand-the-risk", false, "Sicherheitsbestätigung (required unless interactive confirmed)")
-	flag.StringVar(&search, "search", "", "Einfache Volltextsuche (optional)")
-	flag.StringVar(&password, "password", "", "PDF-Passwort (ansonsten verdeckte Abfrage)")
+	var sel selection
+	sel.register(flag.CommandLine)
+	flag.StringVar(&passwordFlag, "password", "", "PDF-Passwort (veraltet: landet in Shell-History und ps; besser --password-file/-env/-fd/-op)")
+	flag.StringVar(&passwordFile, "password-file", "", "PDF-Passwort aus Datei lesen (erste Zeile)")
+	flag.StringVar(&passwordEnv, "password-env", "", "PDF-Passwort aus dieser Umgebungsvariable lesen")
+	flag.IntVar(&passwordFd, "password-fd", -1, "PDF-Passwort aus diesem Dateideskriptor lesen (erste Zeile)")
+	flag.StringVar(&passwordOp, "password-op", "", "PDF-Passwort per op read aus 1Password lesen, z. B. op://Privat/Export/password")
 	flag.BoolVar(&noInteractive, "no-interactive", false, "Interaktive Eingaben ausschalten (z. B. CI)")
 	flag.StringVar(&csvPath, "csv", "", "CSV-Datei als Quelle statt op (optional)")
 	flag.StringVar(&onepuxPath, "onepux", "", ".1pux-Datei als Quelle statt op (optional)")
-	flag.Var(&vaults, "vault", "Name eines Tresors (mehrfach möglich; nur mit op)")
+	flag.StringVar(&sortBy, "sort", "", "Sortierung: "+strings.Join(model.SortKeys, "|")+" (optional)")
+	flag.StringVar(&groupBy, "group-by", "", "Gruppierung: "+strings.Join(pdfwriter.GroupKeys, "|")+" (optional)")
+	flag.StringVar(&label, "label", "", "Vermerk im Seitenkopf, z. B. \"VERTRAULICH\" (optional)")
+	flag.StringVar(&watermark, "watermark", "", "Diagonales Wasserzeichen; {user} und {date} werden ersetzt (optional)")
+	flag.StringVar(&classification, "classification", "", "Einstufung als Balken oben/unten auf jeder Seite (optional)")
+	flag.StringVar(&allow, "allow", "print", "Erlaubte Aktionen mit dem PDF-Passwort: print,copy,modify,annotate|all|none")
+	flag.StringVar(&ownerPwFlag, "owner-password", "", "Eigentümerpasswort für Vollzugriff (sonst zufällig)")
+	flag.StringVar(&ownerPwFile, "owner-password-file", "", "Zufälliges Eigentümerpasswort in diese Datei schreiben (optional)")
+	flag.BoolVar(&showOwnerPw, "show-owner-password", false, "Zufälliges Eigentümerpasswort einmalig auf stderr ausgeben")
+	flag.Var(&recipientCerts, "recipient-cert", "PEM-Zertifikat eines Empfängers statt PDF-Passwort (mehrfach möglich)")
+	flag.StringVar(&shamirSpec, "shamir", "", "Zufälliges PDF-Passwort in Anteile aufteilen, z. B. 3/5 (3 von 5 nötig)")
+	flag.StringVar(&shareDir, "share-dir", "", "Verzeichnis für die Anteilsblätter (Standard: neben --out)")
+	flag.BoolVar(&genPassword, "generate-password", false, "Diceware-Passphrase als PDF-Passwort erzeugen und einmalig anzeigen")
+	flag.IntVar(&minScore, "min-password-score", 3, "Mindeststärke des PDF-Passworts (0–4, zxcvbn)")
+	flag.BoolVar(&allowWeak, "allow-weak-password", false, "Zu schwaches PDF-Passwort trotzdem verwenden")
+	flag.BoolVar(&auditAppendix, "audit-appendix", false, "Passwort-Audit (schwach, mehrfach, ohne TOTP, kompromittiert) als Anhang")
+	flag.StringVar(&hibpPath, "hibp", "", "Lokale HIBP-Liste (SHA-1) für das Audit: Datei oder Verzeichnis mit Präfix-Dateien")
+	flag.StringVar(&totpSites, "2fa-sites", "", "Datei mit Domains, die TOTP anbieten (eine je Zeile; sonst eingebaute Liste)")
+	flag.StringVar(&snapshotPath, "snapshot", "", "Zusätzlich verschlüsselten JSON-Snapshot für diff schreiben (mit dem PDF-Passwort)")
 	flag.Parse()
 
+	if err := model.SortItems(nil, sortBy); err != nil {
+		fail(err)
+	}
+	// vor jedem Zugriff auf 1Password und vor dem Export, damit kein PDF umsonst entsteht
+	var shamirK, shamirN int
+	if shamirSpec != "" {
+		k, n, err := parseShamir(shamirSpec)
+		if err != nil {
+			fail(err)
+		}
+		shamirK, shamirN = k, n
+	}
+	if err := sel.compile(); err != nil {
+		fail(err)
+	}
+	perms, err := pdfcrypt.ParsePermissions(allow)
+	if err != nil {
+		fail(err)
+	}
+	if err := secret.Harden(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warnung: Core-Dumps konnten nicht abgeschaltet werden:", err)
+	}
+	if passwordFlag != "" {
+		fmt.Fprintln(os.Stderr, "Warnung: --password ist veraltet und in Shell-History und Prozessliste sichtbar; besser --password-file, --password-env, --password-fd oder --password-op")
+	}
+	password, err := readPasswordSource(passwordFlag, passwordFile, passwordEnv, passwordFd, passwordOp)
+	if err != nil {
+		fail(err)
+	}
+	// Passwörter werden am Ende von main überschrieben; os.Exit in fail beendet ohnehin den Prozess
+	defer func() { password.Wipe() }()
+	ownerPassword := secret.New(ownerPwFlag)
+	defer func() { ownerPassword.Wipe() }()
+	if len(ownerPassword) > 0 && (ownerPwFile != "" || showOwnerPw) {
+		fail(errors.New("--owner-password-file und --show-owner-password nur ohne --owner-password"))
+	}
+	var recipients []*x509.Certificate
+	for _, path := range recipientCerts {
+		certs, err := pdfcrypt.ReadCertificates(path)
+		if err != nil {
+			fail(err)
+		}
+		recipients = append(recipients, certs...)
+	}
+	if len(recipients) > 0 && (len(password) > 0 || len(ownerPassword) > 0 || ownerPwFile != "" || showOwnerPw) {
+		fail(errors.New("--recipient-cert ersetzt das PDF-Passwort; --password und --owner-password* nicht kombinierbar"))
+	}
+	if snapshotPath != "" && len(recipients) > 0 {
+		fail(errors.New("--snapshot wird mit dem PDF-Passwort verschlüsselt; nicht mit --recipient-cert kombinierbar"))
+	}
+	if shamirSpec != "" {
+		if len(password) > 0 || len(recipients) > 0 {
+			fail(errors.New("--shamir erzeugt das PDF-Passwort selbst; nicht mit --password oder --recipient-cert kombinierbar"))
+		}
+		pw, err := generatePassword()
+		if err != nil {
+			fail(err)
+		}
+		password = pw
+	}
+	var tpl *pdfwriter.Template
+	switch {
+	case template != "" && templateFile != "":
+		fail(errors.New("--template und --template-file nicht kombinierbar"))
+	case templateFile != "":
+		tpl, err = pdfwriter.LoadTemplate(templateFile)
+	case template != "":
+		tpl, err = pdfwriter.BuiltinTemplate(template)
+	case noInteractive:
+		tpl, err = pdfwriter.BuiltinTemplate("compact")
+	}
+	if err != nil {
+		fail(err)
+	}
+	page, err := pdfwriter.ParsePageOptions(pageSize, orientation, margins)
+	if err != nil {
+		fail(err)
+	}
+	if tpl != nil {
+		if err := tpl.ApplyPage(page); err != nil {
+			fail(err)
+		}
+	}
+	if minScore < 0 || minScore > passphrase.MaxScore {
+		fail(fmt.Errorf("--min-password-score muss zwischen 0 und %d liegen", passphrase.MaxScore))
+	}
+	var auditOpt *audit.Options
+	if auditAppendix {
+		o, err := auditOptions(audit.DefaultMinScore, hibpPath, totpSites)
+		if err != nil {
+			fail(err)
+		}
+		o.MaxAge = audit.DefaultMaxAge
+		auditOpt = &o
+	} else if hibpPath != "" || totpSites != "" {
+		fail(errors.New("--hibp und --2fa-sites nur mit --audit-appendix"))
+	}
+	generatedPw := shamirSpec != ""
+	if genPassword {
+		if len(password) > 0 || len(recipients) > 0 || shamirSpec != "" {
+			fail(errors.New("--generate-password nicht mit --password, --recipient-cert oder --shamir kombinierbar"))
+		}
+		pw, err := passphrase.Generate(passphrase.DefaultWords)
+		if err != nil {
+			fail(err)
+		}
+		password = secret.New(pw)
+		generatedPw = true
+	}
+	if _, err := pdfwriter.ParseRedaction(redactSpec, redactMode, redactKeep); err != nil {
+		fail(err)
+	}
+	if outputMode != "plain" && outputMode != "fingerprint" {
+		fail(fmt.Errorf("unbekannter --output-mode %q (erlaubt: plain|fingerprint)", outputMode))
+	}
+	if pwSpelling && outputMode == "fingerprint" {
+		fail(errors.New("--spell-passwords nicht mit --output-mode fingerprint kombinierbar"))
+	}
+	if !validGroupBy(groupBy) {
+		fail(fmt.Errorf("unbekannte Gruppierung %q (erlaubt: %s)", groupBy, strings.Join(pdfwriter.GroupKeys, "|")))
+	}
+
 	mode := detectMode(csvPath, onepuxPath)
 	if !noInteractive {
 		// 1) Risk acceptance if not given
@@ -86,32 +304,41 @@ func main() {
 		}
 
 		// 4) Template
-		if template == "" {
-			template = strings.ToLower(promptStringDefault("Layout (compact/detailed)", "compact"))
-			if template != "compact" && template != "detailed" {
-				template = "compact"
+		for tpl == nil {
+			name := promptStringDefault("Layout ("+strings.Join(pdfwriter.Templates, "/")+")", "compact")
+			if tpl, err = pdfwriter.BuiltinTemplate(name); err == nil {
+				err = tpl.ApplyPage(page)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Fehler:", err)
+				tpl = nil
 			}
 		}
 
-		// 5) Password
-		if strings.TrimSpace(password) == "" {
+		// 5) Password (entfällt bei Empfängerzertifikaten)
+		for password.Empty() && len(recipients) == 0 {
 			var err error
 			password, err = promptPassword()
 			if err != nil { fail(err) }
+			if err := checkPassword(password, minScore, allowWeak, out); err != nil {
+				fmt.Fprintln(os.Stderr, "Fehler:", err)
+				password.Wipe()
+				password = nil
+			}
 		}
 
 		// 6) Optional: mask and search
-		if !maskPw {
+		if !maskPw && redactSpec == "" {
 			if promptYesNo("Passwörter maskieren (•)? (ja/nein): ") {
 				maskPw = true
 			}
 		}
-		if strings.TrimSpace(search) == "" {
-			search = promptStringDefault("Optional: Suchbegriff (leer lassen für alle)", "")
+		if strings.TrimSpace(sel.Search) == "" {
+			sel.Search = promptStringDefault("Optional: Suchbegriff (leer lassen für alle)", "")
 		}
 
 		// 7) Vault selection (op only)
-		if mode == "op" && len(vaults) == 0 {
+		if mode == "op" && len(sel.Vaults) == 0 {
 			// List vaults
 			fmt.Fprintln(os.Stderr, "Lade Tresore...")
 			vlist, err := op.ListVaults()
@@ -121,15 +348,15 @@ func main() {
 			for i, v := range vlist {
 				fmt.Fprintf(os.Stderr, "  [%d] %s\n", i+1, v.Name)
 			}
-			sel := promptString("Auswahl (z. B. 1,3 oder leer für alle): ")
-			if strings.TrimSpace(sel) != "" {
-				parts := strings.Split(sel, ",")
+			choice := promptString("Auswahl (z. B. 1,3 oder leer für alle): ")
+			if strings.TrimSpace(choice) != "" {
+				parts := strings.Split(choice, ",")
 				for _, p := range parts {
 					p = strings.TrimSpace(p)
 					var idx int
 					_, err := fmt.Sscanf(p, "%d", &idx)
 					if err == nil && idx >= 1 && idx <= len(vlist) {
-						vaults = append(vaults, vlist[idx-1].Name)
+						sel.Vaults = append(sel.Vaults, vlist[idx-1].Name)
 					}
 				}
 			}
@@ -142,23 +369,626 @@ func main() {
 		if strings.TrimSpace(out) == "" {
 			fail(errors.New("--out ist erforderlich im --no-interactive Modus"))
 		}
-		if strings.TrimSpace(password) == "" {
-			fail(errors.New("--password ist erforderlich im --no-interactive Modus"))
+		if password.Empty() && len(recipients) == 0 {
+			fail(errors.New("--password-file, --password-env, --password-fd, --password-op, --generate-password oder --recipient-cert ist erforderlich im --no-interactive Modus"))
 		}
-		if template == "" {
-			template = "compact"
+	}
+
+	if len(password) > 0 && !generatedPw {
+		if err := checkPassword(password, minScore, allowWeak, out); err != nil {
+			fail(err)
 		}
 	}
 
 	// Run export
+	generatedOwner := false
+	if len(ownerPassword) == 0 && (ownerPwFile != "" || showOwnerPw) {
+		ownerPassword = pdfwriter.RandomOwnerPassword()
+		generatedOwner = true
+	}
+	redact, err := pdfwriter.ParseRedaction(redactSpec, redactMode, redactKeep)
+	if err != nil {
+		fail(err)
+	}
+	// ein ausdrücklicher Modus aus --redact (auch über all) hat Vorrang vor --mask-passwords
+	if _, ok := redact.Fields["password"]; maskPw && !ok {
+		redact.Fields["password"] = pdfwriter.RedactFull
+	}
+	// CSV und 1PUX kennzeichnen verdeckte Felder nicht; nur op liefert den Feldtyp
+	if redact.Fields["concealed"] != 0 && (mode == "csv" || mode == "1pux") {
+		fmt.Fprintln(os.Stderr, "Warnung: --redact concealed wirkt nur mit der Quelle op; CSV und 1PUX kennzeichnen verdeckte Felder nicht, PINs und andere Zusatzfelder erscheinen im Klartext")
+	}
+	opt := pdfwriter.Options{
+		Template:       tpl,
+		Redact:         redact,
+		PasswordFingerprints: outputMode == "fingerprint",
+		PasswordColors:   pwColors,
+		PasswordSpelling: pwSpelling,
+		UserPassword:   password,
+		OwnerPassword:  ownerPassword,
+		Recipients:     recipients,
+		Permissions:    perms,
+		SortBy:         sortBy,
+		GroupBy:        groupBy,
+		Label:          label,
+		Watermark:      expandPlaceholders(watermark),
+		Classification: classification,
+		Audit:          auditOpt,
+	}
 	switch mode {
 	case "csv":
-		runCSV(csvPath, out, template, maskPw, search, password)
+		runCSV(csvPath, out, sel, snapshotPath, opt)
+	case "1pux":
+		runOnePUX(onepuxPath, out, sel, snapshotPath, opt)
+	default:
+		runOP(sel, out, snapshotPath, opt)
+	}
+
+	if shamirSpec != "" {
+		if err := writeShares(password, shamirK, shamirN, out, shareDir, tpl.Page.Size); err != nil {
+			// Ohne Anteile ist das PDF nicht mehr zu öffnen
+			_ = os.Remove(out)
+			fail(fmt.Errorf("Anteile: %w (PDF entfernt)", err))
+		}
+	}
+
+	if genPassword {
+		fmt.Fprint(os.Stderr, "PDF-Passwort (wird nicht erneut angezeigt, bitte notieren): ")
+		os.Stderr.Write(append(password, '\n'))
+	}
+
+	if generatedOwner {
+		if ownerPwFile != "" {
+			if err := os.WriteFile(ownerPwFile, append(ownerPassword, '\n'), 0o600); err != nil {
+				fail(fmt.Errorf("Eigentümerpasswort: %w", err))
+			}
+			fmt.Fprintln(os.Stderr, "Eigentümerpasswort gespeichert:", ownerPwFile)
+		}
+		if showOwnerPw {
+			fmt.Fprint(os.Stderr, "Eigentümerpasswort (wird nicht erneut angezeigt): ")
+			os.Stderr.Write(append(ownerPassword, '\n'))
+		}
+	}
+}
+
+// expandPlaceholders ersetzt {user} durch den angemeldeten Benutzer und {date} durch das heutige Datum.
+func expandPlaceholders(s string) string {
+	if !strings.Contains(s, "{") {
+		return s
+	}
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return strings.NewReplacer("{user}", name, "{date}", time.Now().Format("2006-01-02")).Replace(s)
+}
+
+func validGroupBy(by string) bool {
+	if strings.TrimSpace(by) == "" {
+		return true
+	}
+	for _, k := range pdfwriter.GroupKeys {
+		if strings.EqualFold(strings.TrimSpace(by), k) {
+			return true
+		}
+	}
+	return false
+}
+
+// readPasswordSource liefert das PDF-Passwort aus höchstens einer der Quellen
+// --password, --password-file, --password-env, --password-fd und --password-op.
+func readPasswordSource(flagValue, file, env string, fd int, ref string) (secret.Bytes, error) {
+	var (
+		sources []string
+		pw      secret.Bytes
+	)
+	if flagValue != "" {
+		sources = append(sources, "--password")
+		pw = secret.New(flagValue)
+	}
+	if file != "" {
+		sources = append(sources, "--password-file")
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("--password-file: %w", err)
+		}
+		pw = secret.FromBytes(firstLine(b))
+		secret.Zero(b)
+	}
+	if env != "" {
+		sources = append(sources, "--password-env")
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			return nil, fmt.Errorf("--password-env: Umgebungsvariable %s ist nicht gesetzt", env)
+		}
+		pw = secret.New(v)
+	}
+	if fd >= 0 {
+		sources = append(sources, "--password-fd")
+		f := os.NewFile(uintptr(fd), "password-fd")
+		if f == nil {
+			return nil, fmt.Errorf("--password-fd: ungültiger Dateideskriptor %d", fd)
+		}
+		line, err := bufio.NewReader(f).ReadSlice('\n')
+		f.Close()
+		if err != nil && len(line) == 0 {
+			return nil, fmt.Errorf("--password-fd: %w", err)
+		}
+		pw = secret.FromBytes(firstLine(line))
+		secret.Zero(line)
+	}
+	if ref != "" {
+		sources = append(sources, "--password-op")
+		v, err := op.Read(ref)
+		if err != nil {
+			return nil, fmt.Errorf("--password-op: %w", err)
+		}
+		pw = v
+	}
+	if len(sources) > 1 {
+		pw.Wipe()
+		return nil, fmt.Errorf("nur eine Passwortquelle erlaubt, angegeben: %s", strings.Join(sources, ", "))
+	}
+	if len(sources) == 1 && len(pw) == 0 {
+		return nil, fmt.Errorf("%s: leeres Passwort", sources[0])
+	}
+	return pw, nil
+}
+
+// firstLine liefert b bis zum ersten Zeilenende.
+func firstLine(b []byte) []byte {
+	if i := bytes.IndexAny(b, "\r\n"); i >= 0 {
+		return b[:i]
+	}
+	return b
+}
+
+// checkPassword lehnt PDF-Passwörter unter der Mindeststärke ab, außer allowWeak ist gesetzt;
+// dann wird nur gewarnt.
+func checkPassword(pw secret.Bytes, minScore int, allowWeak bool, out string) error {
+	st := passphrase.Check(pw.Reveal(), strings.TrimSuffix(filepath.Base(out), filepath.Ext(out)))
+	if st.Score >= minScore {
+		return nil
+	}
+	if allowWeak {
+		fmt.Fprintf(os.Stderr, "Warnung: PDF-Passwort ist %s\n", st)
+		return nil
+	}
+	return fmt.Errorf("PDF-Passwort ist %s, verlangt ist mindestens %d; "+
+		"längeres Passwort wählen, --generate-password nutzen oder --allow-weak-password setzen", st, minScore)
+}
+
+// generatePassword erzeugt ein zufälliges PDF-Passwort mit 160 Bit in Vierergruppen.
+func generatePassword() (secret.Bytes, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	defer secret.Zero(b)
+	enc := make([]byte, base32.StdEncoding.EncodedLen(len(b)))
+	base32.StdEncoding.Encode(enc, b)
+	defer secret.Zero(enc)
+	pw := make(secret.Bytes, 0, len(enc)+len(enc)/4)
+	for i := 0; i < len(enc); i += 4 {
+		if i > 0 {
+			pw = append(pw, '-')
+		}
+		pw = append(pw, enc[i:i+4]...)
+	}
+	defer secret.Zero(pw)
+	return secret.FromBytes(pw), nil
+}
+
+// parseShamir liest --shamir K/N und prüft die Aufteilung wie shamir.Split.
+func parseShamir(spec string) (k, n int, err error) {
+	spec = strings.TrimSpace(spec)
+	if _, err := fmt.Sscanf(spec, "%d/%d", &k, &n); err != nil || spec != fmt.Sprintf("%d/%d", k, n) {
+		return 0, 0, fmt.Errorf("--shamir erwartet K/N, z. B. 3/5, nicht %q", spec)
+	}
+	if err := shamir.CheckSplit(k, n); err != nil {
+		return 0, 0, fmt.Errorf("--shamir %s: %w", spec, err)
+	}
+	return k, n, nil
+}
+
+// writeShares teilt password in n Anteile (k nötig) und schreibt je ein Anteilsblatt
+// im Seitenformat des Exports.
+func writeShares(password secret.Bytes, k, n int, out, dir, pageSize string) error {
+	shares, err := shamir.Split(password, n, k)
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		dir = filepath.Dir(out)
+	}
+	base := strings.TrimSuffix(filepath.Base(out), filepath.Ext(out))
+	for i, sh := range shares {
+		path := filepath.Join(dir, fmt.Sprintf("%s-anteil-%d-von-%d.pdf", base, i+1, n))
+		if err := pdfwriter.WriteShareSheet(path, pdfwriter.ShareSheet{
+			Share:     sh.String(),
+			Number:    i + 1,
+			Total:     n,
+			Threshold: k,
+			Export:    filepath.Base(out),
+			PageSize:  pageSize,
+		}); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Anteil geschrieben:", path)
+	}
+	return nil
+}
+
+// runVerify prüft ein Kandidatenpasswort gegen die Fingerprints eines mit
+// --output-mode fingerprint erzeugten Exports oder gegen einen abgetippten Fingerprint.
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	export := fs.String("export", "", "Mit --output-mode fingerprint erzeugtes PDF")
+	saltStr := fs.String("salt", "", "Salz vom Ausdruck (statt --export)")
+	fpStr := fs.String("fingerprint", "", "Fingerprint vom Ausdruck (mit --salt)")
+	candFile := fs.String("candidate-file", "", "Zu prüfendes Passwort aus Datei lesen (sonst verdeckte Abfrage)")
+	pwFile := fs.String("password-file", "", "PDF-Passwort aus Datei lesen")
+	pwEnv := fs.String("password-env", "", "PDF-Passwort aus dieser Umgebungsvariable lesen")
+	pwFd := fs.Int("password-fd", -1, "PDF-Passwort aus diesem Dateideskriptor lesen")
+	pwOp := fs.String("password-op", "", "PDF-Passwort per op read aus 1Password lesen")
+	_ = fs.Parse(args)
+
+	if (*export == "") == (*saltStr == "") || (*saltStr != "") != (*fpStr != "") {
+		fail(errors.New("verify: entweder --export <PDF> oder --salt und --fingerprint angeben"))
+	}
+
+	var manifest pwhash.Manifest
+	if *export != "" {
+		src, err := os.ReadFile(*export)
+		if err != nil {
+			fail(err)
+		}
+		pdfPw, err := readPasswordSource("", *pwFile, *pwEnv, *pwFd, *pwOp)
+		if err != nil {
+			fail(err)
+		}
+		if len(pdfPw) == 0 {
+			if pdfPw, err = readHidden("PDF-Passwort: "); err != nil {
+				fail(err)
+			}
+		}
+		files, err := pdfcrypt.EmbeddedFiles(src, pdfPw)
+		pdfPw.Wipe()
+		if err != nil {
+			fail(err)
+		}
+		raw, ok := files[pwhash.ManifestName]
+		if !ok {
+			fail(errors.New("verify: keine Fingerprints im PDF (nicht mit --output-mode fingerprint erzeugt?)"))
+		}
+		if err := json.Unmarshal(raw, &manifest); err != nil {
+			fail(fmt.Errorf("verify: %w", err))
+		}
+	} else {
+		salt, err := pwhash.ParseSalt(*saltStr)
+		if err != nil {
+			fail(err)
+		}
+		manifest = pwhash.Manifest{
+			Version: pwhash.Version,
+			KDF:     "argon2id",
+			Params:  pwhash.DefaultParams(salt),
+			Items:   []pwhash.ManifestItem{{Title: "(Ausdruck)", Fingerprint: *fpStr}},
+		}
+	}
+
+	var cand secret.Bytes
+	if *candFile != "" {
+		b, err := os.ReadFile(*candFile)
+		if err != nil {
+			fail(err)
+		}
+		cand = secret.FromBytes(firstLine(b))
+		secret.Zero(b)
+	} else {
+		var err error
+		if cand, err = readHidden("Zu prüfendes Passwort: "); err != nil {
+			fail(err)
+		}
+	}
+	defer cand.Wipe()
+
+	matches, err := manifest.Match(cand)
+	if err != nil {
+		fail(err)
+	}
+	if len(matches) == 0 {
+		fmt.Println("Kein Treffer.")
+		cand.Wipe()
+		os.Exit(1)
+	}
+	for _, m := range matches {
+		if m.Vault != "" {
+			fmt.Printf("Treffer: %s (Tresor %s)\n", m.Title, m.Vault)
+		} else {
+			fmt.Printf("Treffer: %s\n", m.Title)
+		}
+	}
+}
+
+// runAudit prüft die Passwörter einer Quelle offline und gibt den Bericht auf stdout aus.
+// Passwörter selbst erscheinen nie in der Ausgabe.
+func runAudit(args []string) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	csvPath := fs.String("csv", "", "CSV-Datei als Quelle statt op")
+	onepuxPath := fs.String("onepux", "", ".1pux-Datei als Quelle statt op")
+	var sel selection
+	sel.register(fs)
+	hibpPath := fs.String("hibp", "", "Lokale HIBP-Liste (SHA-1): Datei oder Verzeichnis mit Präfix-Dateien")
+	totpSites := fs.String("2fa-sites", "", "Datei mit Domains, die TOTP anbieten (eine je Zeile)")
+	minScore := fs.Int("min-score", audit.DefaultMinScore, "Passwörter unter dieser Stärke (0–4, zxcvbn) gelten als schwach")
+	maxAge := fs.Int("max-age", audit.DefaultMaxAge, "Items, die länger als so viele Tage unverändert sind, gelten als alt (0: aus)")
+	_ = fs.Parse(args)
+
+	if err := sel.compile(); err != nil {
+		fail(err)
+	}
+	if *minScore < 0 || *minScore > passphrase.MaxScore {
+		fail(fmt.Errorf("audit: --min-score muss zwischen 0 und %d liegen", passphrase.MaxScore))
+	}
+	opt, err := auditOptions(*minScore, *hibpPath, *totpSites)
+	if err != nil {
+		fail(err)
+	}
+	opt.MaxAge = *maxAge
+	if err := secret.Harden(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warnung: Core-Dumps konnten nicht abgeschaltet werden:", err)
+	}
+
+	var items []model.Item
+	switch detectMode(*csvPath, *onepuxPath) {
+	case "csv":
+		items, err = model.FromCSV(*csvPath, ",")
+	case "1pux":
+		items, err = onepux.FromFile(*onepuxPath)
+	default:
+		items = loadOP(sel.Vaults, sel.Search)
+	}
+	if err != nil {
+		fail(err)
+	}
+	rep, err := audit.Run(sel.filter(items), opt)
+	model.WipeItems(items)
+	if err != nil {
+		fail(err)
+	}
+
+	fmt.Println(rep.Summary())
+	for _, k := range audit.Kinds {
+		fmt.Printf("  %-20s %d\n", k+":", rep.Count(k))
+	}
+	for _, k := range audit.Kinds {
+		if rep.Count(k) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", k)
+		for _, f := range rep.Findings {
+			if f.Kind != k {
+				continue
+			}
+			title := f.Title
+			if f.Vault != "" {
+				title += " (" + f.Vault + ")"
+			}
+			fmt.Printf("  %s: %s\n", title, f.Detail)
+		}
+	}
+}
+
+// runDiff vergleicht zwei Stände und schreibt einen Änderungsbericht als Text (stdout)
+// oder, mit --out, als verschlüsseltes PDF. Eine Quelle ist ein Snapshot (--snapshot beim
+// Export), eine .csv- oder .1pux-Datei oder "op" für die Live-Daten.
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	oldSrc := fs.String("old", "", "Alter Stand: Snapshot, .csv, .1pux oder op")
+	newSrc := fs.String("new", "op", "Neuer Stand: Snapshot, .csv, .1pux oder op")
+	out := fs.String("out", "", "Bericht als verschlüsseltes PDF schreiben (sonst Text auf stdout)")
+	showSecrets := fs.Bool("show-secrets", false, "Passwörter, TOTP und verdeckte Felder im Klartext zeigen")
+	pageSize := fs.String("page-size", "", "Seitenformat des PDF-Berichts: A4|Letter|Legal|A5|A3")
+	orientation := fs.String("orientation", "", "Ausrichtung des PDF-Berichts: portrait|landscape")
+	margins := fs.String("margins", "", "Ränder des PDF-Berichts in mm (wie beim Export)")
+	var sel selection
+	sel.register(fs)
+	pwFile := fs.String("password-file", "", "Passwort für Snapshots und Bericht aus Datei lesen")
+	pwEnv := fs.String("password-env", "", "Passwort aus dieser Umgebungsvariable lesen")
+	pwFd := fs.Int("password-fd", -1, "Passwort aus diesem Dateideskriptor lesen")
+	pwOp := fs.String("password-op", "", "Passwort per op read aus 1Password lesen")
+	_ = fs.Parse(args)
+
+	if *oldSrc == "" {
+		fail(errors.New("diff: --old fehlt"))
+	}
+	if err := sel.compile(); err != nil {
+		fail(err)
+	}
+	tpl, err := pdfwriter.BuiltinTemplate("compact")
+	if err != nil {
+		fail(err)
+	}
+	page, err := pdfwriter.ParsePageOptions(*pageSize, *orientation, *margins)
+	if err == nil {
+		err = tpl.ApplyPage(page)
+	}
+	if err != nil {
+		fail(err)
+	}
+	if err := secret.Harden(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warnung: Core-Dumps konnten nicht abgeschaltet werden:", err)
+	}
+	password, err := readPasswordSource("", *pwFile, *pwEnv, *pwFd, *pwOp)
+	if err != nil {
+		fail(err)
+	}
+	needPw := *out != "" || sourceKind(*oldSrc) == "snapshot" || sourceKind(*newSrc) == "snapshot"
+	if needPw && len(password) == 0 {
+		if password, err = readHidden("Passwort: "); err != nil {
+			fail(err)
+		}
+	}
+	defer func() { password.Wipe() }()
+
+	oldItems, oldAll, err := loadSource(*oldSrc, sel, password)
+	if err != nil {
+		fail(err)
+	}
+	newItems, newAll, err := loadSource(*newSrc, sel, password)
+	if err != nil {
+		model.WipeItems(oldAll)
+		fail(err)
+	}
+	res := diff.Compare(oldItems, newItems, diff.Options{ShowSecrets: *showSecrets})
+
+	if *out != "" {
+		spec := "password,totp,concealed,cc"
+		if *showSecrets {
+			spec = ""
+		}
+		redact, _ := pdfwriter.ParseRedaction(spec, "full", 2)
+		err = pdfwriter.WriteDiff(*out, res, *oldSrc, *newSrc, pdfwriter.Options{Template: tpl, Redact: redact, UserPassword: password})
+	} else {
+		printDiff(res)
+	}
+	model.WipeItems(oldAll)
+	model.WipeItems(newAll)
+	if err != nil {
+		fail(err)
+	}
+	if *out != "" {
+		fmt.Println("OK:", *out)
+	}
+}
+
+// sourceKind ordnet eine diff-Quelle anhand von Name und Endung zu.
+func sourceKind(src string) string {
+	switch low := strings.ToLower(src); {
+	case low == "op":
+		return "op"
+	case strings.HasSuffix(low, ".csv"):
+		return "csv"
+	case strings.HasSuffix(low, ".1pux"):
+		return "1pux"
+	}
+	return "snapshot"
+}
+
+// loadSource lädt eine diff-Quelle; all enthält auch die nicht ausgewählten Items,
+// damit der Aufrufer deren Geheimnisse überschreiben kann.
+func loadSource(src string, sel selection, password secret.Bytes) (selected, all []model.Item, err error) {
+	switch sourceKind(src) {
+	case "op":
+		all = loadOP(sel.Vaults, sel.Search)
+	case "csv":
+		all, err = model.FromCSV(src, ",")
 	case "1pux":
-		runOnePUX(onepuxPath, out, template, maskPw, search, password)
+		all, err = onepux.FromFile(src)
 	default:
-		runOP(vaults, out, template, maskPw, search, password)
+		var snap snapshot.Snapshot
+		snap, err = snapshot.Read(src, password)
+		all = snap.Items
 	}
+	return sel.filter(all), all, err
+}
+
+// printDiff gibt den Änderungsbericht als Text aus; "+" neu, "-" entfernt, "~" geändert.
+func printDiff(res diff.Result) {
+	fmt.Printf("Neu: %d, Entfernt: %d, Geändert: %d\n", len(res.Added), len(res.Removed), len(res.Modified))
+	name := func(title, vault string) string {
+		if title == "" {
+			title = "(ohne Titel)"
+		}
+		if vault != "" {
+			return title + " (" + vault + ")"
+		}
+		return title
+	}
+	for _, it := range res.Added {
+		fmt.Println("+", name(it.Title, it.Vault))
+	}
+	for _, it := range res.Removed {
+		fmt.Println("-", name(it.Title, it.Vault))
+	}
+	for _, c := range res.Modified {
+		fmt.Println("~", name(c.Title, c.Vault))
+		for _, f := range c.Fields {
+			old, cur := f.Old, f.New
+			if old == "" {
+				old = "(leer)"
+			}
+			if cur == "" {
+				cur = "(leer)"
+			}
+			fmt.Printf("    %s: %s → %s\n", f.Field, old, cur)
+		}
+	}
+}
+
+// auditOptions liest die optionale Domainliste für die TOTP-Prüfung.
+func auditOptions(minScore int, hibpPath, sitesPath string) (audit.Options, error) {
+	opt := audit.Options{MinScore: minScore, HIBPPath: hibpPath}
+	if sitesPath != "" {
+		sites, err := audit.ReadSites(sitesPath)
+		if err != nil {
+			return opt, err
+		}
+		opt.TOTPSites = sites
+	}
+	return opt, nil
+}
+
+// readHidden fragt einmalig verdeckt ab, ohne Wiederholung.
+func readHidden(label string) (secret.Bytes, error) {
+	fmt.Fprint(os.Stderr, label)
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	defer secret.Zero(b)
+	if err != nil {
+		return nil, err
+	}
+	return secret.FromBytes(b), nil
+}
+
+// runCombine setzt das PDF-Passwort aus Anteilen zusammen. Anteile kommen als Argumente
+// oder werden nacheinander abgefragt, bis genug beisammen sind.
+func runCombine(args []string) {
+	var shares []shamir.Share
+	add := func(s string) {
+		sh, err := shamir.Parse(s)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Fehler:", err)
+			return
+		}
+		shares = append(shares, sh)
+	}
+	for _, a := range args {
+		add(a)
+	}
+	for len(shares) == 0 || len(shares) < shares[0].Threshold {
+		label := fmt.Sprintf("Anteil %d: ", len(shares)+1)
+		if len(shares) > 0 {
+			label = fmt.Sprintf("Anteil %d von %d: ", len(shares)+1, shares[0].Threshold)
+		}
+		s := promptString(label)
+		if s == "" {
+			fail(errors.New("abgebrochen"))
+		}
+		add(s)
+	}
+	recovered, err := shamir.Combine(shares)
+	for _, sh := range shares {
+		secret.Zero(sh.Y)
+	}
+	if err != nil {
+		fail(err)
+	}
+	// direkt schreiben, ohne Kopie als String
+	os.Stdout.Write(recovered)
+	fmt.Println()
+	secret.Zero(recovered)
 }
 
 func detectMode(csvPath, onepuxPath string) string {
@@ -203,29 +1033,57 @@ func promptYesNo(label string) bool {
 	}
 }
 
-func promptPassword() (string, error) {
+func promptPassword() (secret.Bytes, error) {
 	fmt.Fprint(os.Stderr, "PDF-Passwort: ")
 	pw1, err := term.ReadPassword(int(os.Stdin.Fd()))
 	fmt.Fprintln(os.Stderr)
+	defer secret.Zero(pw1)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	if len(strings.TrimSpace(string(pw1))) == 0 {
-		return "", errors.New("leeres Passwort ist nicht erlaubt")
+	if secret.Bytes(pw1).Empty() {
+		return nil, errors.New("leeres Passwort ist nicht erlaubt")
 	}
 	fmt.Fprint(os.Stderr, "Passwort wiederholen: ")
 	pw2, err := term.ReadPassword(int(os.Stdin.Fd()))
 	fmt.Fprintln(os.Stderr)
+	defer secret.Zero(pw2)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	if string(pw1) != string(pw2) {
-		return "", errors.New("Passwörter stimmen nicht überein")
+	if !bytes.Equal(pw1, pw2) {
+		return nil, errors.New("Passwörter stimmen nicht überein")
 	}
-	return string(pw1), nil
+	return secret.FromBytes(pw1), nil
+}
+
+func runOP(sel selection, out, snapPath string, opt pdfwriter.Options) {
+	items := loadOP(sel.Vaults, sel.Search)
+	fmt.Fprintln(os.Stderr, "Erzeuge PDF...")
+	opt.Source = "op"
+	export(out, snapPath, sel.filter(items), items, opt)
 }
 
-func runOP(vaults []string, out, template string, maskPw bool, search, password string) {
+// export schreibt das PDF und ggf. den Snapshot und überschreibt danach die Geheimnisse
+// aller geladenen Items (all), nicht nur der exportierten.
+func export(out, snapPath string, items, all []model.Item, opt pdfwriter.Options) {
+	err := pdfwriter.WritePDF(out, items, opt)
+	if err == nil && snapPath != "" {
+		err = snapshot.Write(snapPath, snapshot.Snapshot{Created: time.Now(), Source: opt.Source, Items: items}, opt.UserPassword)
+	}
+	model.WipeItems(all)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Println("OK:", out)
+	if snapPath != "" {
+		fmt.Println("Snapshot:", snapPath)
+	}
+}
+
+// loadOP lädt die Items per op: erst die Liste, dann die Details der Treffer. Die Suche
+// grenzt die Liste nur grob ein (siehe op.MayMatch); der Aufrufer filtert danach genau.
+func loadOP(vaults []string, search string) []model.Item {
 	// 1) Items via op (liste)
 	fmt.Fprintln(os.Stderr, "Lade Item-Liste...")
 	list, err := op.ListItems()
@@ -246,7 +1104,8 @@ func runOP(vaults []string, out, template string, maskPw bool, search, password
 		if len(wantVault) > 0 && !wantVault[strings.ToLower(e.Vault.Name)] {
 			continue
 		}
-		if q != "" && !strings.Contains(strings.ToLower(e.Title), q) {
+		// Suche in zwei Stufen: hier grob auf der Liste, genau in selection.filter
+		if !e.MayMatch(q) {
 			continue
 		}
 		ids = append(ids, pair{e.ID, e.Vault.Name, e.Title})
@@ -257,7 +1116,7 @@ func runOP(vaults []string, out, template string, maskPw bool, search, password
 	}
 
 	// 3) Fortschritt anzeigen
-	fmt.Fprintf(os.Stderr, "Lade Details & rendere PDF (%d Items)...\n", len(ids))
+	fmt.Fprintf(os.Stderr, "Lade Details (%d Items)...\n", len(ids))
 	stop := make(chan struct{})
 	go spinner("Bitte warten", stop)
 
@@ -272,20 +1131,11 @@ func runOP(vaults []string, out, template string, maskPw bool, search, password
 		}
 	}
 	close(stop)
-	fmt.Fprintln(os.Stderr, "Details geladen. Erzeuge PDF...")
-
-	if err := pdfwriter.WritePDF(out, items, pdfwriter.Options{
-		Template:     template,
-		MaskPassword: maskPw,
-		Source:       "op",
-		UserPassword: password,
-	}); err != nil {
-		fail(err)
-	}
-	fmt.Println("OK:", out)
+	fmt.Fprintln(os.Stderr, "Details geladen.")
+	return items
 }
 
-func runCSV(csvPath, out, template string, maskPw bool, search, password string) {
+func runCSV(csvPath, out string, sel selection, snapPath string, opt pdfwriter.Options) {
 	if strings.TrimSpace(csvPath) == "" {
 		fail(errors.New("--csv Pfad fehlt"))
 	}
@@ -293,19 +1143,11 @@ func runCSV(csvPath, out, template string, maskPw bool, search, password string)
 	if err != nil {
 		fail(err)
 	}
-	filtered := filterItems(items, nil, search)
-	if err := pdfwriter.WritePDF(out, filtered, pdfwriter.Options{
-		Template:     template,
-		MaskPassword: maskPw,
-		Source:       "csv",
-		UserPassword: password,
-	}); err != nil {
-		fail(err)
-	}
-	fmt.Println("OK:", out)
+	opt.Source = "csv"
+	export(out, snapPath, sel.filter(items), items, opt)
 }
 
-func runOnePUX(onepuxPath, out, template string, maskPw bool, search, password string) {
+func runOnePUX(onepuxPath, out string, sel selection, snapPath string, opt pdfwriter.Options) {
 	if strings.TrimSpace(onepuxPath) == "" {
 		fail(errors.New("--onepux Pfad fehlt"))
 	}
@@ -313,16 +1155,66 @@ func runOnePUX(onepuxPath, out, template string, maskPw bool, search, password s
 	if err != nil {
 		fail(err)
 	}
-	filtered := filterItems(items, nil, search)
-	if err := pdfwriter.WritePDF(out, filtered, pdfwriter.Options{
-		Template:     template,
-		MaskPassword: maskPw,
-		Source:       "1pux",
-		UserPassword: password,
-	}); err != nil {
-		fail(err)
+	opt.Source = "1pux"
+	export(out, snapPath, sel.filter(items), items, opt)
+}
+
+// selection bündelt die Auswahl der Items, die Export, audit und diff gemeinsam haben.
+type selection struct {
+	Vaults     multiFlag
+	Search     string
+	Filter     string
+	Tags       multiFlag
+	Favorites  bool
+	NoArchived bool
+	expr       query.Expr // aus Filter, gesetzt von compile
+}
+
+func (s *selection) register(fs *flag.FlagSet) {
+	fs.Var(&s.Vaults, "vault", "Name eines Tresors (mehrfach möglich; nur mit op)")
+	fs.StringVar(&s.Search, "search", "", "Einfache Volltextsuche in Titel, Username, URL und Tags (optional)")
+	fs.StringVar(&s.Filter, "filter", "", "Filterausdruck, z. B. 'vault:Privat tag:bank -tag:alt updated>2024-01-01 has:totp'")
+	fs.Var(&s.Tags, "tag", "Nur Items mit diesem Tag, inkl. Unter-Tags wie Arbeit/Server (mehrfach möglich)")
+	fs.BoolVar(&s.Favorites, "favorites", false, "Nur Favoriten")
+	fs.BoolVar(&s.NoArchived, "no-archived", false, "Archivierte Items auslassen")
+}
+
+// compile prüft den Filterausdruck; Syntaxfehler zeigen auf das fehlerhafte Token.
+func (s *selection) compile() error {
+	e, err := query.Parse(s.Filter)
+	if err != nil {
+		return err
 	}
-	fmt.Println("OK:", out)
+	s.expr = e
+	return nil
+}
+
+// filter wendet Suche, Filterausdruck, Tags und Markierungen an; der Tresor wird schon
+// beim Laden per op gefiltert.
+func (s selection) filter(in []model.Item) []model.Item {
+	var out []model.Item
+	for _, it := range filterItems(in, nil, s.Search) {
+		if s.Favorites && !it.Favorite || s.NoArchived && it.Archived {
+			continue
+		}
+		if s.expr != nil && !s.expr.Match(it) {
+			continue
+		}
+		if len(s.Tags) > 0 {
+			ok := false
+			for _, t := range s.Tags {
+				if it.HasTag(t) {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+		}
+		out = append(out, it)
+	}
+	return out
 }
 
 type multiFlag []string
@@ -342,7 +1234,8 @@ func filterItems(in []model.Item, vaults []string, query string) []model.Item {
 		if q := strings.TrimSpace(strings.ToLower(query)); q != "" {
 			if !strings.Contains(strings.ToLower(it.Title), q) &&
 				!strings.Contains(strings.ToLower(it.Username), q) &&
-				!strings.Contains(strings.ToLower(strings.Join(it.URLs, " ")), q) {
+				!strings.Contains(strings.ToLower(strings.Join(it.URLs, " ")), q) &&
+				!strings.Contains(strings.ToLower(strings.Join(it.Tags, " ")), q) {
 				continue
 			}
 		}
@@ -0,0 +1,234 @@
+// Package audit prüft die Passwörter eines Exports offline: schwache Passwörter,
+// Wiederverwendung, fehlendes TOTP bei Diensten, die es anbieten, und Treffer in einer
+// lokal bereitgestellten Have-I-Been-Pwned-Liste. Es gibt keinen Netzwerkzugriff.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/passphrase"
+)
+
+// Kind ist die Art eines Befunds.
+type Kind string
+
+const (
+	KindBreached Kind = "kompromittiert"
+	KindReused   Kind = "mehrfach verwendet"
+	KindWeak     Kind = "schwach"
+	KindNoTOTP   Kind = "ohne TOTP"
+	KindOld      Kind = "alt"
+)
+
+// Kinds listet die Befundarten in der Reihenfolge ihrer Dringlichkeit.
+var Kinds = []Kind{KindBreached, KindReused, KindWeak, KindNoTOTP, KindOld}
+
+// Finding ist ein Befund zu einem Item. Detail enthält nie das Passwort selbst.
+type Finding struct {
+	Title  string
+	Vault  string
+	Kind   Kind
+	Detail string
+}
+
+// DefaultMinScore ist die Mindeststärke (zxcvbn 0–4), unter der ein Passwort als schwach gilt.
+const DefaultMinScore = 3
+
+// DefaultMaxAge ist das Alter in Tagen, ab dem ein Item als alt gilt.
+const DefaultMaxAge = 365
+
+// Options steuert die Prüfungen.
+type Options struct {
+	MinScore  int      // Passwörter unter dieser zxcvbn-Bewertung gelten als schwach
+	HIBPPath  string   // Datei oder Verzeichnis mit SHA-1-Hashes (optional)
+	TOTPSites []string // Domains mit TOTP-Unterstützung; leer = eingebaute Liste
+	// MaxAge in Tagen (0: keine Prüfung). Maßgeblich ist die letzte Änderung des Items, da die
+	// Quellen kein eigenes Datum für das Passwort liefern; Items ohne Zeitstempel zählen nicht.
+	MaxAge int
+	Now    time.Time // Stichtag für MaxAge; Nullwert = jetzt
+}
+
+// Report ist das Ergebnis von Run.
+type Report struct {
+	Items    int  // geprüfte Items mit Passwort
+	HIBP     bool // gegen eine HIBP-Liste geprüft
+	Findings []Finding
+}
+
+// Count liefert die Anzahl der Befunde einer Art.
+func (r Report) Count(k Kind) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Kind == k {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary fasst den Bericht in einem Satz zusammen.
+func (r Report) Summary() string {
+	s := fmt.Sprintf("%d Items mit Passwort geprüft.", r.Items)
+	if !r.HIBP {
+		s += " Ohne HIBP-Liste wurde nicht auf kompromittierte Passwörter geprüft."
+	}
+	return s
+}
+
+// Run prüft alle Items. Die Befunde sind nach Art (siehe Kinds) und Titel sortiert.
+func Run(items []model.Item, opt Options) (Report, error) {
+	var rep Report
+	sites := opt.TOTPSites
+	if len(sites) == 0 {
+		sites = DefaultTOTPSites
+	}
+
+	now := opt.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	byHash := map[[32]byte][]int{}
+	var withPw []int
+	for i, it := range items {
+		if len(it.Password) == 0 {
+			continue
+		}
+		withPw = append(withPw, i)
+		key := sha256.Sum256(it.Password)
+		byHash[key] = append(byHash[key], i)
+
+		if st := passphrase.Check(it.Password.Reveal(), it.Title, it.Username); st.Score < opt.MinScore {
+			rep.add(it, KindWeak, st.String())
+		}
+		if len(it.TOTP) == 0 {
+			if site := totpSite(it.URLs, sites); site != "" {
+				rep.add(it, KindNoTOTP, site+" bietet Zwei-Faktor-Codes an")
+			}
+		}
+		if opt.MaxAge > 0 && !it.Updated.IsZero() {
+			if days := int(now.Sub(it.Updated).Hours() / 24); days > opt.MaxAge {
+				rep.add(it, KindOld, fmt.Sprintf("seit %d Tagen unverändert (%s)", days, it.Updated.Format("2006-01-02")))
+			}
+		}
+	}
+	rep.Items = len(withPw)
+
+	for _, idx := range byHash {
+		if len(idx) < 2 {
+			continue
+		}
+		for _, i := range idx {
+			var others []string
+			for _, j := range idx {
+				if j != i {
+					others = append(others, displayTitle(items[j]))
+				}
+			}
+			sort.Strings(others)
+			rep.add(items[i], KindReused, "auch in: "+strings.Join(others, ", "))
+		}
+	}
+
+	if opt.HIBPPath != "" {
+		pws := make([][]byte, len(withPw))
+		for k, i := range withPw {
+			pws[k] = items[i].Password
+		}
+		counts, err := lookupHIBP(opt.HIBPPath, pws)
+		if err != nil {
+			return rep, err
+		}
+		rep.HIBP = true
+		for k, i := range withPw {
+			if n := counts[k]; n > 0 {
+				rep.add(items[i], KindBreached, fmt.Sprintf("%d-mal in Datenlecks gesehen", n))
+			}
+		}
+	}
+
+	rank := map[Kind]int{}
+	for i, k := range Kinds {
+		rank[k] = i
+	}
+	sort.SliceStable(rep.Findings, func(i, j int) bool {
+		a, b := rep.Findings[i], rep.Findings[j]
+		if a.Kind != b.Kind {
+			return rank[a.Kind] < rank[b.Kind]
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+	return rep, nil
+}
+
+func (r *Report) add(it model.Item, k Kind, detail string) {
+	r.Findings = append(r.Findings, Finding{Title: displayTitle(it), Vault: it.Vault, Kind: k, Detail: detail})
+}
+
+func displayTitle(it model.Item) string {
+	if it.Title == "" {
+		return "(ohne Titel)"
+	}
+	return it.Title
+}
+
+// totpSite liefert die erste Domain aus sites, zu der eine der URLs gehört.
+func totpSite(urls []string, sites []string) string {
+	for _, raw := range urls {
+		if !strings.Contains(raw, "://") {
+			raw = "https://" + raw
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		host := strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
+		for _, s := range sites {
+			if host == s || strings.HasSuffix(host, "."+s) {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// ReadSites liest eine Domain je Zeile; Leerzeilen und Zeilen mit "#" werden übersprungen.
+func ReadSites(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	defer f.Close()
+	var sites []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sites = append(sites, strings.TrimPrefix(line, "www."))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("audit: %s: %w", path, err)
+	}
+	return sites, nil
+}
+
+// DefaultTOTPSites ist eine kleine Auswahl verbreiteter Dienste mit TOTP-Unterstützung.
+// Eine vollständige Liste (z. B. aus 2fa.directory) lässt sich per Datei übergeben.
+var DefaultTOTPSites = []string{
+	"amazon.com", "amazon.de", "apple.com", "atlassian.com", "bitbucket.org", "binance.com",
+	"cloudflare.com", "coinbase.com", "digitalocean.com", "discord.com", "dropbox.com",
+	"ebay.com", "ebay.de", "facebook.com", "github.com", "gitlab.com", "google.com",
+	"heroku.com", "instagram.com", "linkedin.com", "live.com", "mailbox.org", "microsoft.com",
+	"npmjs.com", "paypal.com", "posteo.de", "proton.me", "protonmail.com", "reddit.com",
+	"slack.com", "steampowered.com", "twitch.tv", "twitter.com", "x.com", "zoom.us",
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lookupHIBP zählt, wie oft jedes Passwort in der lokalen Liste vorkommt. path ist entweder
+//   - ein Verzeichnis mit einer Datei je 5-stelligem Präfix (Name "5BAA6" oder "5BAA6.txt",
+//     Zeilen "SUFFIX:ANZAHL" wie bei der Range-API bzw. dem PwnedPasswordsDownloader), oder
+//   - eine Datei mit vollständigen Hashes, eine Zeile "HASH:ANZAHL" je Eintrag.
+//
+// Die Passwörter verlassen den Rechner nicht; verglichen wird nur ihr SHA-1.
+func lookupHIBP(path string, pws [][]byte) ([]int, error) {
+	hashes := make([]string, len(pws))
+	for i, pw := range pws {
+		sum := sha1.Sum(pw)
+		hashes[i] = strings.ToUpper(hex.EncodeToString(sum[:]))
+	}
+	counts := make([]int, len(pws))
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("audit: HIBP-Liste: %w", err)
+	}
+	if !fi.IsDir() {
+		want := map[string][]int{}
+		for i, h := range hashes {
+			want[h] = append(want[h], i)
+		}
+		err := scanHashFile(path, func(hash string, n int) {
+			for _, i := range want[hash] {
+				counts[i] = n
+			}
+		})
+		return counts, err
+	}
+
+	byPrefix := map[string][]int{}
+	for i, h := range hashes {
+		byPrefix[h[:5]] = append(byPrefix[h[:5]], i)
+	}
+	for prefix, idx := range byPrefix {
+		file := filepath.Join(path, prefix)
+		if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+			file += ".txt"
+		}
+		err := scanHashFile(file, func(suffix string, n int) {
+			for _, i := range idx {
+				if hashes[i][5:] == suffix {
+					counts[i] = n
+				}
+			}
+		})
+		if err != nil {
+			// eine fehlende Präfix-Datei würde Treffer stillschweigend verschweigen
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+// scanHashFile ruft fn für jede Zeile "HASH[:ANZAHL]" auf; ohne Anzahl gilt 1.
+func scanHashFile(path string, fn func(hash string, n int)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("audit: HIBP-Liste: %w", err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		hash, count, found := strings.Cut(line, ":")
+		n := 1
+		if found {
+			if v, err := strconv.Atoi(strings.TrimSpace(count)); err == nil {
+				n = v
+			}
+		}
+		fn(strings.ToUpper(hash), n)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("audit: HIBP-Liste %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Package diff vergleicht zwei Stände eines Exports, z. B. den Snapshot vom Vormonat
+// mit der aktuellen op-Quelle, und liefert neue, entfernte und geänderte Items.
+package diff
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+)
+
+// Masked ersetzt geheime Werte, wenn Options.ShowSecrets nicht gesetzt ist.
+const Masked = "••••••••"
+
+// Options steuert den Vergleich.
+type Options struct {
+	ShowSecrets bool // Passwörter, TOTP und verdeckte Felder im Klartext zeigen
+}
+
+// FieldChange ist ein geändertes Feld; ein leerer Wert heißt "nicht gesetzt".
+type FieldChange struct {
+	Field  string
+	Old    string
+	New    string
+	Secret bool
+}
+
+// Change beschreibt ein Item, das in beiden Ständen vorkommt und sich unterscheidet.
+type Change struct {
+	ID     string
+	Title  string
+	Vault  string
+	Fields []FieldChange
+}
+
+// Result ist das Ergebnis von Compare; alle Listen sind nach Titel sortiert.
+type Result struct {
+	Added    []model.Item
+	Removed  []model.Item
+	Modified []Change
+}
+
+// Empty meldet, ob sich nichts geändert hat.
+func (r Result) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Modified) == 0
+}
+
+// Compare ordnet die Items beider Stände einander zu: zuerst über die Item-ID, für Items
+// ohne ID (CSV) oder ohne Gegenstück mit ID über Tresor und Titel.
+func Compare(old, cur []model.Item, opt Options) Result {
+	var res Result
+	matched := make([]bool, len(old))
+	byID := map[string]int{}
+	byName := map[string][]int{}
+	for i, it := range old {
+		if it.ID != "" {
+			byID[it.ID] = i
+		}
+		byName[nameKey(it)] = append(byName[nameKey(it)], i)
+	}
+	take := func(it model.Item) int {
+		if it.ID != "" {
+			if i, ok := byID[it.ID]; ok && !matched[i] {
+				return i
+			}
+		}
+		for _, i := range byName[nameKey(it)] {
+			// ein Gegenstück mit anderer ID ist ein anderes Item gleichen Namens
+			if !matched[i] && (it.ID == "" || old[i].ID == "" || old[i].ID == it.ID) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, it := range cur {
+		i := take(it)
+		if i < 0 {
+			res.Added = append(res.Added, it)
+			continue
+		}
+		matched[i] = true
+		if fields := compareItems(old[i], it, opt); len(fields) > 0 {
+			id := it.ID
+			if id == "" {
+				id = old[i].ID
+			}
+			res.Modified = append(res.Modified, Change{ID: id, Title: displayTitle(it), Vault: it.Vault, Fields: fields})
+		}
+	}
+	for i, it := range old {
+		if !matched[i] {
+			res.Removed = append(res.Removed, it)
+		}
+	}
+
+	byTitle := func(items []model.Item) {
+		sort.SliceStable(items, func(i, j int) bool {
+			return strings.ToLower(items[i].Title) < strings.ToLower(items[j].Title)
+		})
+	}
+	byTitle(res.Added)
+	byTitle(res.Removed)
+	sort.SliceStable(res.Modified, func(i, j int) bool {
+		return strings.ToLower(res.Modified[i].Title) < strings.ToLower(res.Modified[j].Title)
+	})
+	return res
+}
+
+func nameKey(it model.Item) string {
+	return strings.ToLower(strings.TrimSpace(it.Vault)) + "\x00" + strings.ToLower(strings.TrimSpace(it.Title))
+}
+
+// compareItems liefert die geänderten Felder in der Reihenfolge, in der das PDF sie zeigt.
+func compareItems(a, b model.Item, opt Options) []FieldChange {
+	var out []FieldChange
+	cmp := func(field, old, cur string) {
+		if old != cur {
+			out = append(out, FieldChange{Field: field, Old: old, New: cur})
+		}
+	}
+	// Geheimnisse werden als Bytes verglichen und nur bei ShowSecrets zu Strings
+	cmpSecret := func(field string, old, cur []byte) {
+		if bytes.Equal(old, cur) {
+			return
+		}
+		fc := FieldChange{Field: field, Old: mask(old), New: mask(cur), Secret: true}
+		if opt.ShowSecrets {
+			fc.Old, fc.New = string(old), string(cur)
+		}
+		out = append(out, fc)
+	}
+
+	cmp("Titel", a.Title, b.Title)
+	cmp("Tresor", a.Vault, b.Vault)
+	cmp("Kategorie", a.Category, b.Category)
+	cmp("Username", a.Username, b.Username)
+	cmpSecret("Passwort", a.Password, b.Password)
+	cmp("URL", strings.Join(a.URLs, " "), strings.Join(b.URLs, " "))
+	cmpSecret("TOTP", a.TOTP, b.TOTP)
+	cmp("Notizen", a.Notes, b.Notes)
+	cmp("Tags", strings.Join(a.Tags, ", "), strings.Join(b.Tags, ", "))
+	cmp("Favorit", yes(a.Favorite), yes(b.Favorite))
+	cmp("Archiviert", yes(a.Archived), yes(b.Archived))
+
+	keys := map[string]bool{}
+	for k := range a.RawFields {
+		keys[k] = true
+	}
+	for k := range b.RawFields {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		if a.Concealed[k] || b.Concealed[k] {
+			cmpSecret(k, a.RawFields[k], b.RawFields[k])
+		} else {
+			cmp(k, string(a.RawFields[k]), string(b.RawFields[k]))
+		}
+	}
+	return out
+}
+
+func yes(b bool) string {
+	if b {
+		return "ja"
+	}
+	return ""
+}
+
+func mask(v []byte) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return Masked
+}
+
+func displayTitle(it model.Item) string {
+	if it.Title == "" {
+		return "(ohne Titel)"
+	}
+	return it.Title
+}
@@ -0,0 +1,119 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/secret"
+)
+
+// titles fasst die Titel einer Liste zusammen, z. B. "Bank,Mail".
+func titles(items []model.Item) string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.Title)
+	}
+	return strings.Join(out, ",")
+}
+
+// changes fasst geänderte Items zusammen, z. B. "Bank[Passwort URL]".
+func changes(cs []Change) string {
+	var out []string
+	for _, c := range cs {
+		var fields []string
+		for _, f := range c.Fields {
+			fields = append(fields, f.Field)
+		}
+		out = append(out, c.Title+"["+strings.Join(fields, " ")+"]")
+	}
+	return strings.Join(out, ",")
+}
+
+func TestCompare(t *testing.T) {
+	bank := model.Item{ID: "b1", Title: "Bank", Vault: "Privat", Username: "anna", Password: secret.New("alt"), URLs: []string{"https://bank.example"}}
+	mail := model.Item{ID: "m1", Title: "Mail", Vault: "Arbeit", Username: "a.muster"}
+	with := func(it model.Item, edit func(*model.Item)) model.Item {
+		edit(&it)
+		return it
+	}
+	noID := func(it model.Item) model.Item { it.ID = ""; return it }
+
+	tests := []struct {
+		name                     string
+		old, cur                 []model.Item
+		added, removed, modified string
+	}{
+		{"unverändert", []model.Item{bank, mail}, []model.Item{mail, bank}, "", "", ""},
+		{"neu und entfernt", []model.Item{bank}, []model.Item{mail}, "Mail", "Bank", ""},
+		{"leer", nil, []model.Item{bank, mail}, "Bank,Mail", "", ""},
+		// gleiche ID: umbenannt und verschoben ist eine Änderung, kein neues Item
+		{"umbenannt", []model.Item{bank}, []model.Item{with(bank, func(it *model.Item) { it.Title, it.Vault = "Sparkasse", "Familie" })}, "", "", "Sparkasse[Titel Tresor]"},
+		{"Felder", []model.Item{bank}, []model.Item{with(bank, func(it *model.Item) {
+			it.Password, it.URLs, it.Tags, it.Favorite = secret.New("neu"), []string{"https://bank.example/login"}, []string{"Finanzen"}, true
+		})}, "", "", "Bank[Passwort URL Tags Favorit]"},
+		// ohne ID (CSV) über Tresor und Titel, ohne Rücksicht auf Schreibweise
+		{"CSV", []model.Item{noID(bank)}, []model.Item{with(noID(bank), func(it *model.Item) { it.Title, it.Vault = " bank ", "PRIVAT"; it.Username = "anna2" })}, "", "", " bank [Titel Tresor Username]"},
+		{"alt ohne ID", []model.Item{noID(bank)}, []model.Item{bank}, "", "", ""},
+		{"neu ohne ID", []model.Item{bank}, []model.Item{noID(bank)}, "", "", ""},
+		// gleicher Name, andere ID: ein anderes Item
+		{"Namensvetter", []model.Item{bank}, []model.Item{with(bank, func(it *model.Item) { it.ID = "b2" })}, "Bank", "Bank", ""},
+		{"Duplikate", []model.Item{noID(bank), noID(bank)}, []model.Item{noID(bank)}, "", "Bank", ""},
+		{"sortiert", nil, []model.Item{{Title: "zeta"}, {Title: "Alpha"}, {Title: "beta"}}, "Alpha,beta,zeta", "", ""},
+	}
+	for _, tt := range tests {
+		res := Compare(tt.old, tt.cur, Options{})
+		if got := titles(res.Added); got != tt.added {
+			t.Errorf("%s: neu %q, erwartet %q", tt.name, got, tt.added)
+		}
+		if got := titles(res.Removed); got != tt.removed {
+			t.Errorf("%s: entfernt %q, erwartet %q", tt.name, got, tt.removed)
+		}
+		if got := changes(res.Modified); got != tt.modified {
+			t.Errorf("%s: geändert %q, erwartet %q", tt.name, got, tt.modified)
+		}
+		if res.Empty() != (tt.added == "" && tt.removed == "" && tt.modified == "") {
+			t.Errorf("%s: Empty() = %v", tt.name, res.Empty())
+		}
+	}
+}
+
+func TestCompareSecrets(t *testing.T) {
+	old := model.Item{ID: "b1", Title: "Bank", Password: secret.New("alt"), TOTP: secret.New("otpauth://alt"),
+		RawFields: map[string]secret.Bytes{"PIN": secret.New("1234"), "Filiale": secret.New("Nord")}, Concealed: map[string]bool{"PIN": true}}
+	cur := model.Item{ID: "b1", Title: "Bank",
+		RawFields: map[string]secret.Bytes{"PIN": secret.New("9999"), "Filiale": secret.New("Süd")}, Concealed: map[string]bool{"PIN": true}}
+
+	tests := []struct {
+		show bool
+		want []FieldChange
+	}{
+		{false, []FieldChange{
+			{Field: "Passwort", Old: Masked, Secret: true},
+			{Field: "TOTP", Old: Masked, Secret: true},
+			{Field: "Filiale", Old: "Nord", New: "Süd"},
+			{Field: "PIN", Old: Masked, New: Masked, Secret: true},
+		}},
+		{true, []FieldChange{
+			{Field: "Passwort", Old: "alt", Secret: true},
+			{Field: "TOTP", Old: "otpauth://alt", Secret: true},
+			{Field: "Filiale", Old: "Nord", New: "Süd"},
+			{Field: "PIN", Old: "1234", New: "9999", Secret: true},
+		}},
+	}
+	for _, tt := range tests {
+		res := Compare([]model.Item{old}, []model.Item{cur}, Options{ShowSecrets: tt.show})
+		if len(res.Modified) != 1 {
+			t.Fatalf("ShowSecrets %v: %d Änderungen", tt.show, len(res.Modified))
+		}
+		got := res.Modified[0].Fields
+		if len(got) != len(tt.want) {
+			t.Fatalf("ShowSecrets %v: %+v, erwartet %+v", tt.show, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ShowSecrets %v: %+v, erwartet %+v", tt.show, got[i], tt.want[i])
+			}
+		}
+	}
+}
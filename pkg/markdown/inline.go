@@ -0,0 +1,217 @@
+package markdown
+
+import "strings"
+
+// Span ist ein Textstück mit einheitlicher Auszeichnung.
+type Span struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Code   bool
+	Link   string // Ziel, wenn das Stück ein Link ist
+}
+
+// Inline zerlegt eine Zeile in Spans: **fett**, *kursiv* (auch mit _), `Code`,
+// [Text](URL), <URL> sowie nackte http(s)-Adressen. Backslash maskiert Satzzeichen.
+func Inline(s string) []Span {
+	p := &inliner{src: s}
+	p.run(Span{}, true)
+	return p.out
+}
+
+type inliner struct {
+	src string
+	i   int
+	buf strings.Builder
+	cur Span
+	out []Span
+}
+
+func (p *inliner) flush() {
+	if p.buf.Len() == 0 {
+		return
+	}
+	sp := p.cur
+	sp.Text = p.buf.String()
+	p.buf.Reset()
+	if n := len(p.out); n > 0 && sameStyle(p.out[n-1], sp) {
+		p.out[n-1].Text += sp.Text
+		return
+	}
+	p.out = append(p.out, sp)
+}
+
+func sameStyle(a, b Span) bool {
+	return a.Bold == b.Bold && a.Italic == b.Italic && a.Code == b.Code && a.Link == b.Link
+}
+
+// emit hängt einen fertigen Span an, z. B. Code oder eine Adresse.
+func (p *inliner) emit(sp Span) {
+	p.flush()
+	p.out = append(p.out, sp)
+}
+
+// run arbeitet src ab; links ist false im Text eines Links, der keine weiteren enthalten darf.
+func (p *inliner) run(base Span, links bool) {
+	p.cur = base
+	s := p.src
+	for p.i < len(s) {
+		c := s[p.i]
+		switch {
+		case c == '\\' && p.i+1 < len(s) && strings.IndexByte("\\`*_{}[]()#+-.!|<>~", s[p.i+1]) >= 0:
+			p.buf.WriteByte(s[p.i+1])
+			p.i += 2
+
+		case c == '`':
+			n := run(s[p.i:], '`')
+			fence := strings.Repeat("`", n)
+			end := strings.Index(s[p.i+n:], fence)
+			if end < 0 {
+				p.buf.WriteString(fence)
+				p.i += n
+				continue
+			}
+			code := p.cur
+			code.Code, code.Text = true, strings.TrimSpace(s[p.i+n:p.i+n+end])
+			p.emit(code)
+			p.i += n + end + n
+
+		case c == '*' || c == '_':
+			n := min(run(s[p.i:], c), 3)
+			if !p.toggle(c, n) {
+				p.buf.WriteString(s[p.i : p.i+n])
+			}
+			p.i += n
+
+		case links && (c == '[' || c == '!' && strings.HasPrefix(s[p.i:], "![")):
+			if !p.link() {
+				p.buf.WriteByte(c)
+				p.i++
+			}
+
+		case links && c == '<':
+			end := strings.IndexByte(s[p.i:], '>')
+			if u := s[p.i+1 : p.i+max(end, 1)]; end > 0 && isURL(u) && !strings.Contains(u, " ") {
+				sp := p.cur
+				sp.Text, sp.Link = strings.TrimPrefix(u, "mailto:"), u
+				p.emit(sp)
+				p.i += end + 1
+				continue
+			}
+			p.buf.WriteByte(c)
+			p.i++
+
+		case links && (c == 'h' || c == 'H') && isURL(s[p.i:]) && (p.i == 0 || !isWord(s[p.i-1])):
+			end := strings.IndexAny(s[p.i:], " <>")
+			if end < 0 {
+				end = len(s) - p.i
+			}
+			// Satzzeichen am Ende gehören meist zum Satz, nicht zur Adresse
+			u := strings.TrimRight(s[p.i:p.i+end], ".,;:!?")
+			if strings.HasSuffix(u, ")") && !strings.Contains(u, "(") {
+				u = u[:len(u)-1]
+			}
+			sp := p.cur
+			sp.Text, sp.Link = u, u
+			p.emit(sp)
+			p.i += len(u)
+
+		default:
+			p.buf.WriteByte(c)
+			p.i++
+		}
+	}
+	p.flush()
+}
+
+// toggle schaltet fett (n=2), kursiv (n=1) oder beides (n=3) um, wenn der Begrenzer an
+// dieser Stelle öffnen bzw. schließen kann. Unterstriche innerhalb von Wörtern zählen nicht.
+func (p *inliner) toggle(c byte, n int) bool {
+	s := p.src
+	var before, after byte = ' ', ' '
+	if p.i > 0 {
+		before = s[p.i-1]
+	}
+	if p.i+n < len(s) {
+		after = s[p.i+n]
+	}
+	if c == '_' && isWord(before) && isWord(after) {
+		return false
+	}
+	active := n == 1 && p.cur.Italic || n == 2 && p.cur.Bold || n == 3 && p.cur.Bold && p.cur.Italic
+	canClose := active && before != ' ' && !(c == '_' && isWord(after))
+	canOpen := !active && after != ' ' && !(c == '_' && isWord(before)) &&
+		strings.Contains(s[p.i+n:], strings.Repeat(string(c), n))
+	if !canClose && !canOpen {
+		return false
+	}
+	p.flush()
+	if n != 2 {
+		p.cur.Italic = !p.cur.Italic
+	}
+	if n != 1 {
+		p.cur.Bold = !p.cur.Bold
+	}
+	return true
+}
+
+// link liest [Text](URL "Titel") bzw. ![Alt](URL) ab p.i; Bilder erscheinen als Link.
+func (p *inliner) link() bool {
+	s := p.src[p.i:]
+	start := strings.IndexByte(s, '[')
+	depth, end := 0, -1
+	for j := start; j < len(s) && end < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				end = j
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return false
+	}
+	rp := strings.IndexByte(s[end:], ')')
+	if rp < 0 {
+		return false
+	}
+	target := strings.TrimSpace(s[end+2 : end+rp])
+	if f := strings.Fields(target); len(f) > 0 {
+		target = strings.Trim(f[0], "<>")
+	}
+	text := s[start+1 : end]
+	if text == "" {
+		text = target
+	}
+	base := p.cur
+	base.Link = target
+	sub := &inliner{src: text}
+	sub.run(base, false)
+	p.flush()
+	for _, sp := range sub.out {
+		p.emit(sp)
+	}
+	p.i += end + rp + 1
+	return true
+}
+
+func run(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func isWord(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func isURL(s string) bool {
+	l := strings.ToLower(s)
+	return strings.HasPrefix(l, "http://") || strings.HasPrefix(l, "https://") || strings.HasPrefix(l, "mailto:")
+}
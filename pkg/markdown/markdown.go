@@ -0,0 +1,228 @@
+// Package markdown zerlegt die Markdown-Notizen von 1Password in Blöcke und Inline-Spans,
+// die pdfwriter setzt. Unterstützt wird, was in Notizen vorkommt: Überschriften, Absätze,
+// Aufzählungen und nummerierte Listen (auch verschachtelt und mit [ ]/[x]), Zitate,
+// Codeblöcke (eingerückt oder mit ``` bzw. ~~~), Trennlinien, Tabellen sowie fett, kursiv,
+// Code und Links im Text. Zeilenumbrüche innerhalb eines Absatzes bleiben wie in 1Password
+// erhalten, ebenso die Einrückung von Absatzzeilen und Code; nur Leerzeichen am Zeilenende
+// fallen weg.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kind ist die Art eines Blocks.
+type Kind int
+
+const (
+	Paragraph Kind = iota
+	Heading
+	ListItem
+	Quote
+	Code
+	Rule
+	Table
+)
+
+// Align ist die Ausrichtung einer Tabellenspalte.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Block ist ein Abschnitt der Notiz.
+type Block struct {
+	Kind   Kind
+	Level  int        // Heading: 1–6; ListItem: Verschachtelungstiefe ab 0
+	Marker string     // ListItem: "•", "3." oder "[x]"
+	Lines  [][]Span   // Paragraph, Heading, ListItem, Quote: Zeilen mit hartem Umbruch
+	Text   []string   // Code: Zeilen ohne Einrückung des Blocks
+	Align  []Align    // Table: je Spalte
+	Rows   [][][]Span // Table: Zeilen, Zellen, Spans; die erste Zeile ist der Kopf
+}
+
+var (
+	headingRe = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	listRe    = regexp.MustCompile(`^( *)([-*+]|\d{1,9}[.)])(?:\s+(.*))?$`)
+	taskRe    = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	quoteRe   = regexp.MustCompile(`^ {0,3}>\s?(.*)$`)
+	delimRe   = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+// Parse zerlegt src in Blöcke. Tabs zählen als vier Leerzeichen.
+func Parse(src string) []Block {
+	src = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\t", "    ").Replace(src)
+	lines := strings.Split(src, "\n")
+	var out []Block
+	cur := -1 // offener Block, an den Folgezeilen angehängt werden
+	for i := 0; i < len(lines); i++ {
+		l := strings.TrimRight(lines[i], " ")
+		t := strings.TrimSpace(l) // nur zum Erkennen der Syntax
+		indent := len(l) - len(strings.TrimLeft(l, " "))
+
+		if t == "" {
+			cur = -1
+			continue
+		}
+		if fence := fenceOf(t); fence != "" {
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, strings.TrimPrefix(strings.TrimRight(lines[i], " "), strings.Repeat(" ", indent)))
+			}
+			out = append(out, Block{Kind: Code, Text: code})
+			cur = -1
+			continue
+		}
+		if indent >= 4 && cur < 0 {
+			var code []string
+			for ; i < len(lines); i++ {
+				l := strings.TrimRight(lines[i], " ")
+				if l != "" && !strings.HasPrefix(l, "    ") {
+					break
+				}
+				code = append(code, strings.TrimPrefix(l, "    "))
+			}
+			i--
+			for len(code) > 0 && code[len(code)-1] == "" {
+				code = code[:len(code)-1]
+			}
+			out = append(out, Block{Kind: Code, Text: code})
+			continue
+		}
+		if m := headingRe.FindStringSubmatch(l); m != nil {
+			out = append(out, Block{Kind: Heading, Level: len(m[1]), Lines: [][]Span{Inline(m[2])}})
+			cur = -1
+			continue
+		}
+		if cur >= 0 && out[cur].Kind == Paragraph && len(out[cur].Lines) == 1 && (strings.Trim(t, "=") == "" || strings.Trim(t, "-") == "") {
+			// Setext-Überschrift: Text mit === oder --- darunter
+			out[cur].Kind, out[cur].Level = Heading, 2
+			if t[0] == '=' {
+				out[cur].Level = 1
+			}
+			cur = -1
+			continue
+		}
+		if isRule(t) {
+			out = append(out, Block{Kind: Rule})
+			cur = -1
+			continue
+		}
+		if strings.Contains(l, "|") && i+1 < len(lines) && strings.Contains(lines[i+1], "-") && delimRe.MatchString(lines[i+1]) {
+			b := Block{Kind: Table, Rows: [][][]Span{cells(l)}}
+			for _, c := range splitRow(lines[i+1]) {
+				c = strings.TrimSpace(c)
+				switch {
+				case strings.HasPrefix(c, ":") && strings.HasSuffix(c, ":"):
+					b.Align = append(b.Align, AlignCenter)
+				case strings.HasSuffix(c, ":"):
+					b.Align = append(b.Align, AlignRight)
+				default:
+					b.Align = append(b.Align, AlignLeft)
+				}
+			}
+			for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+				b.Rows = append(b.Rows, cells(lines[i]))
+			}
+			i--
+			out = append(out, b)
+			cur = -1
+			continue
+		}
+		if m := listRe.FindStringSubmatch(l); m != nil {
+			marker, text := m[2], m[3]
+			if len(marker) == 1 && strings.Contains("-*+", marker) {
+				marker = "•"
+			}
+			if tm := taskRe.FindStringSubmatch(text); tm != nil {
+				marker = "[" + strings.ToLower(tm[1]) + "]"
+				text = text[len(tm[0]):]
+			}
+			out = append(out, Block{Kind: ListItem, Level: len(m[1]) / 2, Marker: marker, Lines: [][]Span{Inline(text)}})
+			cur = len(out) - 1
+			continue
+		}
+		if m := quoteRe.FindStringSubmatch(l); m != nil {
+			if cur >= 0 && out[cur].Kind == Quote {
+				out[cur].Lines = append(out[cur].Lines, Inline(m[1]))
+			} else {
+				out = append(out, Block{Kind: Quote, Lines: [][]Span{Inline(m[1])}})
+				cur = len(out) - 1
+			}
+			continue
+		}
+		if cur >= 0 && out[cur].Kind == Paragraph {
+			// Folgezeile eines Absatzes; die Einrückung bleibt wie in 1Password
+			out[cur].Lines = append(out[cur].Lines, Inline(l))
+			continue
+		}
+		if cur >= 0 {
+			// Folgezeile eines Listenpunkts oder Zitats
+			out[cur].Lines = append(out[cur].Lines, Inline(t))
+			continue
+		}
+		out = append(out, Block{Kind: Paragraph, Lines: [][]Span{Inline(l)}})
+		cur = len(out) - 1
+	}
+	return out
+}
+
+// fenceOf liefert den Zaun eines Codeblocks (``` oder ~~~), sonst "".
+func fenceOf(t string) string {
+	for _, f := range []string{"```", "~~~"} {
+		if strings.HasPrefix(t, f) {
+			return f
+		}
+	}
+	return ""
+}
+
+// isRule erkennt Trennlinien aus mindestens drei -, * oder _ (Leerzeichen erlaubt).
+func isRule(t string) bool {
+	t = strings.ReplaceAll(t, " ", "")
+	if len(t) < 3 {
+		return false
+	}
+	for _, c := range []string{"-", "*", "_"} {
+		if strings.Trim(t, c) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// splitRow trennt eine Tabellenzeile an | (nicht an \|), ohne die äußeren Striche.
+func splitRow(l string) []string {
+	l = strings.TrimSpace(l)
+	l = strings.TrimPrefix(l, "|")
+	if strings.HasSuffix(l, "|") && !strings.HasSuffix(l, `\|`) {
+		l = l[:len(l)-1]
+	}
+	var out []string
+	var b strings.Builder
+	for i := 0; i < len(l); i++ {
+		switch {
+		case l[i] == '\\' && i+1 < len(l) && l[i+1] == '|':
+			b.WriteByte('|')
+			i++
+		case l[i] == '|':
+			out = append(out, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(l[i])
+		}
+	}
+	return append(out, b.String())
+}
+
+func cells(l string) [][]Span {
+	var out [][]Span
+	for _, c := range splitRow(l) {
+		out = append(out, Inline(strings.TrimSpace(c)))
+	}
+	return out
+}
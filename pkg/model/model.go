@@ -6,20 +6,63 @@ import (
 	"errors"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/example/onepw-pdf-export/pkg/secret"
 )
 
-// Item ist ein vereinheitlichtes Modell für das PDF.
+// Item ist ein vereinheitlichtes Modell für das PDF. Die JSON-Namen bilden das Format
+// der verschlüsselten Snapshots (siehe pkg/snapshot) und bleiben daher stabil.
 type Item struct {
-	Title    string
-	Category string
-	Vault    string
-	Username string
-	Password string
-	URLs     []string
-	Notes    string
-	TOTP     string
+	// ID ist die Item-ID der Quelle (op, 1PUX); bei CSV leer.
+	ID       string       `json:"id,omitempty"`
+	Title    string       `json:"title"`
+	Category string       `json:"category,omitempty"`
+	Vault    string       `json:"vault,omitempty"`
+	Username string       `json:"username,omitempty"`
+	Password secret.Bytes `json:"password,omitempty"`
+	URLs     []string     `json:"urls,omitempty"`
+	Notes    string       `json:"notes,omitempty"`
+	TOTP     secret.Bytes `json:"totp,omitempty"`
 	// RawFields enthält alle Label->Value-Paare, die nicht in die Standardfelder fielen.
-	RawFields map[string]string
+	// Sie können verdeckte Felder (PINs, Sicherheitsfragen) enthalten und gelten daher als geheim.
+	RawFields map[string]secret.Bytes `json:"fields,omitempty"`
+	// Concealed markiert Zusatzfelder, die in der Quelle verdeckt sind (Typ CONCEALED).
+	Concealed map[string]bool `json:"concealed,omitempty"`
+	// Created und Updated sind die Zeitstempel der Quelle; bei CSV Nullwerte.
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+	Tags     []string  `json:"tags,omitempty"`
+	Favorite bool      `json:"favorite,omitempty"`
+	Archived bool      `json:"archived,omitempty"`
+}
+
+// HasTag meldet, ob das Item den Tag trägt (ohne Rücksicht auf Groß-/Kleinschreibung).
+// Verschachtelte Tags wie "Arbeit/Server" zählen auch für "Arbeit".
+func (it Item) HasTag(tag string) bool {
+	tag = strings.Trim(strings.TrimSpace(tag), "/")
+	for _, t := range it.Tags {
+		if strings.EqualFold(t, tag) || (len(t) > len(tag) && strings.EqualFold(t[:len(tag)], tag) && t[len(tag)] == '/') {
+			return true
+		}
+	}
+	return false
+}
+
+// Wipe überschreibt Passwort, TOTP und Zusatzfelder des Items.
+func (it *Item) Wipe() {
+	it.Password.Wipe()
+	it.TOTP.Wipe()
+	for _, v := range it.RawFields {
+		v.Wipe()
+	}
+}
+
+// WipeItems ruft Wipe für alle items auf, z. B. nachdem das PDF geschrieben ist.
+func WipeItems(items []Item) {
+	for i := range items {
+		items[i].Wipe()
+	}
 }
 
 // FromCSV parst eine 1Password-CSV (Logins). Spalten können je nach Export variieren.
@@ -77,10 +120,10 @@ func FromCSV(path, delimiter string) ([]Item, error) {
 			Category: "login",
 			Vault:    "",
 			Username: get(iUser),
-			Password: get(iPass),
+			Password: secret.New(get(iPass)),
 			URLs:     nil,
 			Notes:    get(iNotes),
-			RawFields: map[string]string{},
+			RawFields: map[string]secret.Bytes{},
 		}
 		if iURL >= 0 {
 			u := strings.TrimSpace(get(iURL))
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortKeys listet die von SortItems unterstützten Sortierschlüssel.
+var SortKeys = []string{"title", "vault", "category", "updated", "created"}
+
+// SortItems sortiert items stabil nach key (title|vault|category|updated|created).
+// Ein leerer key behält die Reihenfolge der Quelle bei. Gleichstände werden nach Titel aufgelöst.
+// Zeitstempel sortieren absteigend (neueste zuerst), Items ohne Zeitstempel stehen am Ende.
+func SortItems(items []Item, key string) error {
+	var field func(Item) string
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case "":
+		return nil
+	case "title":
+		field = func(it Item) string { return it.Title }
+	case "vault":
+		field = func(it Item) string { return it.Vault }
+	case "category":
+		field = func(it Item) string { return it.Category }
+	case "updated":
+		sortByTime(items, func(it Item) time.Time { return it.Updated })
+		return nil
+	case "created":
+		sortByTime(items, func(it Item) time.Time { return it.Created })
+		return nil
+	default:
+		return fmt.Errorf("unbekannte Sortierung %q (erlaubt: %s)", key, strings.Join(SortKeys, "|"))
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := strings.ToLower(field(items[i])), strings.ToLower(field(items[j]))
+		if a != b {
+			return a < b
+		}
+		return strings.ToLower(items[i].Title) < strings.ToLower(items[j].Title)
+	})
+	return nil
+}
+
+func sortByTime(items []Item, at func(Item) time.Time) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := at(items[i]), at(items[j])
+		if !a.Equal(b) {
+			if a.IsZero() || b.IsZero() {
+				return b.IsZero()
+			}
+			return a.After(b)
+		}
+		return strings.ToLower(items[i].Title) < strings.ToLower(items[j].Title)
+	})
+}
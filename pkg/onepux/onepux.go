@@ -7,8 +7,10 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/secret"
 )
 
 // FromFile parst eine .1pux ZIP best-effort.
@@ -81,11 +83,12 @@ func mapGeneric(g map[string]interface{}) (model.Item, bool) {
 	if title == "" { return model.Item{}, false }
 
 	it := model.Item{
+		ID:       getS("uuid", "id"),
 		Title:    title,
 		Vault:    getS("vault", "vaultName"),
 		Category: getS("category", "type"),
 		URLs:     []string{},
-		RawFields: map[string]string{},
+		RawFields: map[string]secret.Bytes{},
 	}
 	// häufige Felder
 	user := getS("username", "user", "login", "loginUsername")
@@ -94,9 +97,24 @@ func mapGeneric(g map[string]interface{}) (model.Item, bool) {
 	totp := getS("totp", "otp", "oneTimePassword")
 
 	it.Username = user
-	it.Password = pass
+	it.Password = secret.New(pass)
 	it.Notes = notes
-	it.TOTP = totp
+	it.TOTP = secret.New(totp)
+
+	// Metadaten: Zeitstempel als Unix-Sekunden (1PUX) oder RFC 3339, Tags auch unter overview
+	it.Created = getTime(g, "createdAt", "created_at", "created")
+	it.Updated = getTime(g, "updatedAt", "updated_at", "updated")
+	it.Tags = getTags(g)
+	if ov, ok := g["overview"].(map[string]interface{}); ok && len(it.Tags) == 0 {
+		it.Tags = getTags(ov)
+	}
+	if n, ok := g["favIndex"].(float64); ok && n > 0 {
+		it.Favorite = true
+	}
+	if b, ok := g["favorite"].(bool); ok && b {
+		it.Favorite = true
+	}
+	it.Archived = strings.EqualFold(getS("state"), "archived")
 
 	// URLs (einzelne Felder)
 	u := getS("url", "website")
@@ -106,12 +124,39 @@ func mapGeneric(g map[string]interface{}) (model.Item, bool) {
 	for k, v := range g {
 		if _, ok := v.(string); ok {
 			ks := strings.ToLower(k)
-			if ks == "title" || ks == "name" || ks == "username" || ks == "password" || ks == "notes" || ks == "notesplain" || ks == "totp" || ks == "otp" || ks == "onetimepassword" || ks == "url" || ks == "website" {
+			if ks == "uuid" || ks == "id" || ks == "state" || ks == "createdat" || ks == "updatedat" || ks == "created_at" || ks == "updated_at" || ks == "created" || ks == "updated" || ks == "title" || ks == "name" || ks == "username" || ks == "password" || ks == "notes" || ks == "notesplain" || ks == "totp" || ks == "otp" || ks == "onetimepassword" || ks == "url" || ks == "website" {
 				continue
 			}
-			it.RawFields[k] = v.(string)
+			it.RawFields[k] = secret.New(v.(string))
 		}
 	}
 
 	return it, true
 }
+
+func getTime(g map[string]interface{}, keys ...string) time.Time {
+	for _, k := range keys {
+		switch v := g[k].(type) {
+		case float64:
+			if v > 0 {
+				return time.Unix(int64(v), 0).UTC()
+			}
+		case string:
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func getTags(g map[string]interface{}) []string {
+	raw, _ := g["tags"].([]interface{})
+	var tags []string
+	for _, t := range raw {
+		if s, ok := t.(string); ok && strings.TrimSpace(s) != "" {
+			tags = append(tags, s)
+		}
+	}
+	return tags
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/secret"
 )
 
 type Vault struct {
@@ -18,12 +20,43 @@ type Vault struct {
 
 // opItemListEntry spiegelt die grobe Struktur von `op item list --format json`.
 type opItemListEntry struct {
-	ID       string `json:"id"`
-	Title    string `json:"title"`
-	Category string `json:"category"`
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
 	Vault    struct {
 		Name string `json:"name"`
 	} `json:"vault"`
+	// AdditionalInformation ist bei Logins der Username, bei anderen Kategorien z. B.
+	// die letzten Ziffern einer Karte.
+	AdditionalInformation string `json:"additional_information"`
+	URLs                  []struct {
+		Href string `json:"href"`
+	} `json:"urls"`
+}
+
+// MayMatch ist die erste Stufe von --search auf der Item-Liste: Sie verwirft nur Items,
+// die sicher nicht passen, damit nicht für jedes Item die Details geladen werden müssen.
+// Die eigentliche Suche läuft nach dem Laden auf denselben Feldern wie bei CSV und 1PUX.
+//
+// Titel, URLs und Tags stehen vollständig in der Liste. Den Username enthält sie nur bei
+// Logins (als additional_information); andere Kategorien können ein Username-Feld haben
+// und werden daher immer geladen. q muss kleingeschrieben sein.
+func (e opItemListEntry) MayMatch(q string) bool {
+	if q == "" || !strings.EqualFold(e.Category, "LOGIN") {
+		return true
+	}
+	fields := []string{e.Title, e.AdditionalInformation}
+	fields = append(fields, e.Tags...)
+	for _, u := range e.URLs {
+		fields = append(fields, u.Href)
+	}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), q) {
+			return true
+		}
+	}
+	return false
 }
 
 // opItemDetail spiegelt `op item get <id> --format json` minimal.
@@ -37,14 +70,21 @@ type opItemDetail struct {
 	Fields []struct {
 		ID    string      `json:"id"`
 		Label string      `json:"label"`
-		Type  string      `json:"type"` // e.g., CONCEALED for password
-		Value interface{} `json:"value"`
+		Type    string      `json:"type"`    // e.g., CONCEALED for password
+		Purpose string      `json:"purpose"` // USERNAME | PASSWORD | NOTES bei Logins
+		Value   interface{} `json:"value"`
 	} `json:"fields"`
 	URLs []struct {
 		Label string `json:"label"`
 		Href  string `json:"href"`
 	} `json:"urls"`
 	NotesPlain string `json:"notesPlain"`
+	// Zeitstempel als String, damit ein abweichendes Format nicht das ganze Item verwirft
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+	Tags      []string `json:"tags"`
+	Favorite  bool     `json:"favorite"`
+	State     string   `json:"state"` // "ARCHIVED" für archivierte Items
 }
 
 // ListVaults ruft alle Tresore ab.
@@ -105,11 +145,18 @@ func FetchAllItems() ([]model.Item, error) {
 
 func mapDetail(d opItemDetail) model.Item {
 	it := model.Item{
+		ID:       d.ID,
 		Title:    d.Title,
 		Category: d.Category,
 		Vault:    d.Vault.Name,
 		URLs:     []string{},
-		RawFields: map[string]string{},
+		RawFields: map[string]secret.Bytes{},
+		Concealed: map[string]bool{},
+		Created:  parseTime(d.CreatedAt),
+		Updated:  parseTime(d.UpdatedAt),
+		Tags:     d.Tags,
+		Favorite: d.Favorite,
+		Archived: strings.EqualFold(d.State, "ARCHIVED"),
 	}
 	for _, u := range d.URLs {
 		if strings.TrimSpace(u.Href) != "" {
@@ -125,23 +172,53 @@ func mapDetail(d opItemDetail) model.Item {
 		lbl := strings.TrimSpace(f.Label)
 		typ := strings.ToUpper(strings.TrimSpace(f.Type))
 
-		// heuristisch
+		// heuristisch; Kartennummern, CVV und SSH-Schlüssel bleiben Zusatzfelder für die
+		// kategoriespezifische Darstellung, sind aber verdeckt
+		secretType := typ == "CONCEALED" || typ == "CREDIT_CARD_NUMBER" || typ == "SSHKEY"
 		switch {
-		case strings.EqualFold(lbl, "username") || strings.Contains(strings.ToLower(lbl), "user"):
+		case f.Purpose == "USERNAME" || strings.EqualFold(lbl, "username") || strings.Contains(strings.ToLower(lbl), "user"):
 			if it.Username == "" { it.Username = val }
-		case strings.EqualFold(lbl, "password") || typ == "CONCEALED":
-			if it.Password == "" { it.Password = val }
+		case f.Purpose == "PASSWORD" || strings.EqualFold(lbl, "password") || typ == "CONCEALED" && hasPassword(d.Category):
+			if it.Password == nil {
+				it.Password = secret.New(val)
+			} else if lbl != "" && val != "" {
+				// weitere verdeckte Felder (PIN, Sicherheitsantwort) nicht verwerfen
+				it.RawFields[lbl] = secret.New(val)
+				it.Concealed[lbl] = true
+			}
 		case strings.Contains(strings.ToLower(lbl), "otp") || strings.Contains(strings.ToLower(lbl), "totp"):
-			if it.TOTP == "" { it.TOTP = val }
+			if it.TOTP == nil { it.TOTP = secret.New(val) }
 		default:
 			if lbl != "" && val != "" {
-				it.RawFields[lbl] = val
+				it.RawFields[lbl] = secret.New(val)
+				if secretType {
+					it.Concealed[lbl] = true
+				}
 			}
 		}
 	}
 	return it
 }
 
+// hasPassword meldet, ob das erste verdeckte Feld einer Kategorie ihr Passwort ist.
+// Bei Karten, Bankkonten usw. sind verdeckte Felder PIN oder Prüfnummer.
+func hasPassword(category string) bool {
+	switch strings.ToUpper(category) {
+	case "", "LOGIN", "PASSWORD", "DATABASE", "SERVER", "WIRELESS_ROUTER", "EMAIL_ACCOUNT":
+		return true
+	}
+	return false
+}
+
+// parseTime liest die RFC-3339-Zeitstempel von op; unlesbare ergeben den Nullwert.
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 func stringify(v interface{}) string {
 	switch t := v.(type) {
 	case string:
@@ -154,8 +231,25 @@ func stringify(v interface{}) string {
 	}
 }
 
+// Read löst eine Secret-Referenz wie op://Tresor/Item/Feld über `op read` auf.
+func Read(ref string) (secret.Bytes, error) {
+	if !strings.HasPrefix(ref, "op://") {
+		return nil, fmt.Errorf("op: ungültige Referenz %q (erwartet op://tresor/item/feld)", ref)
+	}
+	raw, err := runOp("read", "--no-newline", ref)
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Zero(raw)
+	return secret.FromBytes(raw), nil
+}
+
 func runOpJSON(args ...string) ([]byte, error) {
-	cmd := exec.Command("op", append(args, "--format", "json")...)
+	return runOp(append(args, "--format", "json")...)
+}
+
+func runOp(args ...string) ([]byte, error) {
+	cmd := exec.Command("op", args...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
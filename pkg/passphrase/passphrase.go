@@ -0,0 +1,48 @@
+package passphrase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nbutton23/zxcvbn-go"
+	"github.com/sethvargo/go-diceware/diceware"
+)
+
+// DefaultWords ist die Wortzahl für Generate; sechs Wörter der EFF-Liste ergeben rund 77 Bit.
+const DefaultWords = 6
+
+// MaxScore ist die höchste Bewertung von Check.
+const MaxScore = 4
+
+// Strength ist das Ergebnis einer Passwortbewertung nach zxcvbn.
+type Strength struct {
+	Score   int     // 0 (sehr schwach) bis 4 (sehr stark)
+	Entropy float64 // geschätzte Entropie in Bit
+}
+
+// scoreNames benennt die Bewertungen 0..4.
+var scoreNames = []string{"sehr schwach", "schwach", "mäßig", "stark", "sehr stark"}
+
+// String beschreibt die Bewertung, z. B. "schwach (1/4, ~23 Bit)".
+func (s Strength) String() string {
+	return fmt.Sprintf("%s (%d/%d, ~%.0f Bit)", scoreNames[s.Score], s.Score, MaxScore, s.Entropy)
+}
+
+// Check schätzt die Stärke von pw. hints sind Begriffe aus dem Kontext (Dateiname, Benutzer),
+// die ein Angreifer zuerst probieren würde.
+func Check(pw string, hints ...string) Strength {
+	m := zxcvbn.PasswordStrength(pw, append([]string{"1password", "onepassword", "vault", "export", "tresor"}, hints...))
+	return Strength{Score: m.Score, Entropy: m.Entropy}
+}
+
+// Generate erzeugt eine Diceware-Passphrase aus words Wörtern der EFF-Liste, getrennt durch "-".
+func Generate(words int) (string, error) {
+	if words < 4 {
+		return "", fmt.Errorf("mindestens 4 Wörter nötig, nicht %d", words)
+	}
+	list, err := diceware.Generate(words)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(list, "-"), nil
+}
@@ -0,0 +1,198 @@
+package pdfcrypt
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+var (
+	reEncrypt = regexp.MustCompile(`/Encrypt\s+(\d+)\s+\d+\s+R`)
+	reEF      = regexp.MustCompile(`/EF\s*<<\s*/F\s+(\d+)\s+\d+\s+R`)
+)
+
+// ErrWrongPassword meldet, dass weder Benutzer- noch Eigentümerpasswort passen.
+var ErrWrongPassword = errors.New("pdfcrypt: falsches Passwort")
+
+// EmbeddedFiles öffnet ein mit Encrypt per Passwort verschlüsseltes PDF und liefert die
+// eingebetteten Dateien (entpackt) nach Dateiname. Benutzer- und Eigentümerpasswort
+// werden beide akzeptiert; Zertifikats-Verschlüsselung wird nicht unterstützt.
+func EmbeddedFiles(src, password []byte) (map[string][]byte, error) {
+	doc, trailer, err := readObjects(src)
+	if err != nil {
+		return nil, err
+	}
+	m := reEncrypt.FindSubmatch(trailer)
+	if m == nil {
+		return nil, errors.New("pdfcrypt: Dokument ist nicht verschlüsselt")
+	}
+	encNum, _ := strconv.Atoi(string(m[1]))
+	key, err := standardKey(doc.objects[encNum], password)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, body := range doc.objects {
+		if !bytes.Contains(body, []byte("/Filespec")) {
+			continue
+		}
+		ef := reEF.FindSubmatch(body)
+		if ef == nil {
+			continue
+		}
+		name, err := filespecName(body, key)
+		if err != nil {
+			return nil, err
+		}
+		n, _ := strconv.Atoi(string(ef[1]))
+		data, err := streamData(doc.objects[n], key)
+		if err != nil {
+			return nil, fmt.Errorf("pdfcrypt: eingebettete Datei %q: %w", name, err)
+		}
+		files[name] = data
+	}
+	return files, nil
+}
+
+// standardKey prüft password gegen das /Encrypt-Dictionary (Revision 6) und liefert
+// den Dateischlüssel (ISO 32000-2, Algorithmus 2.A).
+func standardKey(dict, password []byte) ([]byte, error) {
+	if !bytes.Contains(dict, []byte("/Standard")) || !regexp.MustCompile(`/R\s+6\b`).Match(dict) {
+		return nil, errors.New("pdfcrypt: nur Passwort-Verschlüsselung mit AES-256 (Revision 6) wird unterstützt")
+	}
+	u, ue := dictString(dict, "U"), dictString(dict, "UE")
+	o, oe := dictString(dict, "O"), dictString(dict, "OE")
+	if len(u) < 48 || len(o) < 48 || len(ue) != 32 || len(oe) != 32 {
+		return nil, errors.New("pdfcrypt: ungültiges /Encrypt-Dictionary")
+	}
+	pw := passwordBytes(password)
+
+	var kek, wrapped []byte
+	switch {
+	case subtle.ConstantTimeCompare(hash2B(pw, u[32:40], nil), u[:32]) == 1:
+		kek, wrapped = hash2B(pw, u[40:48], nil), ue
+	case subtle.ConstantTimeCompare(hash2B(pw, o[32:40], u[:48]), o[:32]) == 1:
+		kek, wrapped = hash2B(pw, o[40:48], u[:48]), oe
+	default:
+		return nil, ErrWrongPassword
+	}
+	c, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, len(wrapped))
+	cipher.NewCBCDecrypter(c, make([]byte, aes.BlockSize)).CryptBlocks(key, wrapped)
+	return key, nil
+}
+
+// dictString liefert den String-Wert des Schlüssels /name, wie Encrypt ihn schreibt (<hex>).
+func dictString(dict []byte, name string) []byte {
+	m := regexp.MustCompile(`/` + name + `\s*<([0-9A-Fa-f\s]*)>`).FindSubmatch(dict)
+	if m == nil {
+		return nil
+	}
+	b, err := hexString(m[1])
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// filespecName liefert den Dateinamen (/UF) einer Dateispezifikation.
+func filespecName(body, key []byte) (string, error) {
+	at := bytes.Index(body, []byte("/UF"))
+	if at < 0 {
+		return "", errors.New("pdfcrypt: Dateispezifikation ohne /UF")
+	}
+	var raw []byte
+	var decErr error
+	err := scan(body[at:], func(tok token) bool {
+		if tok.kind == tokString {
+			raw, decErr = decryptAES(tok.value, key)
+			return false
+		}
+		return true
+	})
+	if err == nil {
+		err = decErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return textString(raw), nil
+}
+
+// streamData entschlüsselt den Stream eines Objekts und entpackt ihn bei /FlateDecode.
+func streamData(body, key []byte) ([]byte, error) {
+	var data []byte
+	err := scan(body, func(tok token) bool {
+		if tok.kind == tokStream {
+			data = tok.value
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, errors.New("kein Stream")
+	}
+	plain, err := decryptAES(data, key)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(body, []byte("/FlateDecode")) {
+		return plain, nil
+	}
+	r, err := zlib.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// decryptAES kehrt encryptAES um.
+func decryptAES(data, key []byte) ([]byte, error) {
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("ungültige AES-Daten")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(out, data[aes.BlockSize:])
+	pad := int(out[len(out)-1])
+	if pad < 1 || pad > aes.BlockSize || pad > len(out) {
+		return nil, errors.New("ungültiges Padding")
+	}
+	return out[:len(out)-pad], nil
+}
+
+// textString dekodiert einen PDF-Textstring: UTF-16BE mit BOM oder PDFDocEncoding,
+// letzteres hier vereinfacht als Latin-1.
+func textString(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
@@ -0,0 +1,361 @@
+package pdfcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Permission steuert, was ein Leser mit dem Benutzerpasswort darf.
+// Die Werte entsprechen den Bits des /P-Eintrags (ISO 32000-2, Tabelle 22).
+type Permission uint32
+
+const (
+	PermPrint    Permission = 1 << 2
+	PermModify   Permission = 1 << 3
+	PermCopy     Permission = 1 << 4
+	PermAnnotate Permission = 1 << 5
+)
+
+// PermissionNames ordnet die Namen für --allow den Rechten zu.
+var PermissionNames = map[string]Permission{
+	"print":    PermPrint,
+	"copy":     PermCopy,
+	"modify":   PermModify,
+	"annotate": PermAnnotate,
+}
+
+// ParsePermissions liest eine kommagetrennte Liste wie "print,copy".
+// "none" und die leere Liste bedeuten keine Rechte, "all" alle.
+func ParsePermissions(s string) (Permission, error) {
+	var perms Permission
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "", "none":
+			continue
+		case "all":
+			perms |= PermPrint | PermCopy | PermModify | PermAnnotate
+			continue
+		}
+		p, ok := PermissionNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unbekanntes Recht %q (erlaubt: print, copy, modify, annotate, all, none)", name)
+		}
+		perms |= p
+	}
+	return perms, nil
+}
+
+// Options beschreibt die Verschlüsselung für Encrypt. Mit Recipients wird statt eines
+// Passworts der Public-Key-Handler verwendet: jedes Zertifikat kann das PDF öffnen.
+type Options struct {
+	UserPassword  []byte              // zum Öffnen (Pflicht ohne Recipients)
+	OwnerPassword []byte              // für Vollzugriff; leer = UserPassword
+	Recipients    []*x509.Certificate // RSA-Zertifikate der Empfänger (optional)
+	Permissions   Permission          // Rechte mit Benutzerpasswort bzw. für die Empfänger
+}
+
+// handler liefert Dateischlüssel und /Encrypt-Dictionary eines Security-Handlers.
+type handler interface {
+	key() []byte
+	dict() string
+}
+
+// Encrypt verschlüsselt ein unverschlüsseltes PDF mit klassischer Cross-Reference-Tabelle
+// (wie gofpdf es erzeugt) mit AES-256 für alle Strings und Streams: per Passwort über den
+// Standard-Security-Handler Revision 6 oder per Zertifikat über adbe.pkcs7.s5 (ISO 32000-2).
+// Das Ergebnis wird vollständig im Speicher erzeugt; es entstehen keine Zwischendateien.
+func Encrypt(src []byte, opt Options) ([]byte, error) {
+	var h handler
+	var err error
+	switch {
+	case len(opt.Recipients) > 0:
+		if len(opt.UserPassword) > 0 || len(opt.OwnerPassword) > 0 {
+			return nil, errors.New("pdfcrypt: Passwort und Empfängerzertifikate schließen sich aus")
+		}
+		h, err = newPubSecHandler(opt.Recipients, opt.Permissions)
+	case len(opt.UserPassword) == 0:
+		return nil, errors.New("pdfcrypt: Benutzerpasswort ist leer")
+	default:
+		owner := opt.OwnerPassword
+		if len(owner) == 0 {
+			owner = opt.UserPassword
+		}
+		h, err = newStandardHandler(opt.UserPassword, owner, opt.Permissions)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return doc.write(h.key(), h.dict())
+}
+
+// permissionBits liefert den /P-Wert. Reservierte Bits sind gesetzt, "Barrierefreiheit
+// extrahieren" ist in PDF 2.0 veraltet und immer erlaubt.
+func permissionBits(perms Permission) int32 {
+	p := uint32(0xFFFFF0C0) | 0x200
+	if perms&PermPrint != 0 {
+		p |= 0x4 | 0x800 // inkl. hochwertiger Druck
+	}
+	if perms&PermModify != 0 {
+		p |= 0x8 | 0x400 // inkl. Seiten zusammenstellen
+	}
+	if perms&PermCopy != 0 {
+		p |= 0x10
+	}
+	if perms&PermAnnotate != 0 {
+		p |= 0x20 | 0x100 // inkl. Formulare ausfüllen
+	}
+	return int32(p)
+}
+
+// document ist ein zerlegtes PDF: Objekte nach Nummer plus die Trailer-Verweise.
+type document struct {
+	objects map[int][]byte // Objektinhalt zwischen "n g obj" und "endobj"
+	root    int
+	info    int
+}
+
+var (
+	reStartXref = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	reObjHeader = regexp.MustCompile(`^(\d+)\s+(\d+)\s+obj\b`)
+	reRoot      = regexp.MustCompile(`/Root\s+(\d+)\s+\d+\s+R`)
+	reInfo      = regexp.MustCompile(`/Info\s+(\d+)\s+\d+\s+R`)
+	reLength    = regexp.MustCompile(`/Length(\s+)(\d+)`)
+)
+
+// parse liest ein unverschlüsseltes PDF mit readObjects.
+func parse(src []byte) (*document, error) {
+	doc, trailer, err := readObjects(src)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Contains(trailer, []byte("/Encrypt")) {
+		return nil, errors.New("pdfcrypt: Dokument ist bereits verschlüsselt")
+	}
+	return doc, nil
+}
+
+// readObjects liest Cross-Reference-Tabelle und Trailer und schneidet alle Objekte aus.
+// Unterstützt wird bewusst nur, was gofpdf und Encrypt schreiben: eine Tabelle, keine Objekt-Streams.
+func readObjects(src []byte) (*document, []byte, error) {
+	m := reStartXref.FindSubmatch(src)
+	if m == nil {
+		return nil, nil, errors.New("pdfcrypt: startxref nicht gefunden")
+	}
+	pos, _ := strconv.Atoi(string(m[1]))
+	if pos >= len(src) || !bytes.HasPrefix(src[pos:], []byte("xref")) {
+		return nil, nil, errors.New("pdfcrypt: nur klassische xref-Tabellen werden unterstützt")
+	}
+	rest := src[pos+len("xref"):]
+	trailerAt := bytes.Index(rest, []byte("trailer"))
+	if trailerAt < 0 {
+		return nil, nil, errors.New("pdfcrypt: trailer nicht gefunden")
+	}
+	trailer := rest[trailerAt:]
+
+	doc := &document{objects: map[int][]byte{}}
+	fields := bytes.Fields(rest[:trailerAt])
+	for i := 0; i+1 < len(fields); {
+		first, err1 := strconv.Atoi(string(fields[i]))
+		count, err2 := strconv.Atoi(string(fields[i+1]))
+		if err1 != nil || err2 != nil || i+2+3*count > len(fields) {
+			return nil, nil, errors.New("pdfcrypt: ungültige xref-Tabelle")
+		}
+		i += 2
+		for n := first; n < first+count; n, i = n+1, i+3 {
+			if string(fields[i+2]) != "n" {
+				continue
+			}
+			off, err := strconv.Atoi(string(fields[i]))
+			if err != nil || off >= len(src) {
+				return nil, nil, fmt.Errorf("pdfcrypt: ungültiger Offset für Objekt %d", n)
+			}
+			body, err := objectBody(src[off:], n)
+			if err != nil {
+				return nil, nil, err
+			}
+			doc.objects[n] = body
+		}
+	}
+
+	if m := reRoot.FindSubmatch(trailer); m != nil {
+		doc.root, _ = strconv.Atoi(string(m[1]))
+	}
+	if m := reInfo.FindSubmatch(trailer); m != nil {
+		doc.info, _ = strconv.Atoi(string(m[1]))
+	}
+	if _, ok := doc.objects[doc.root]; !ok {
+		return nil, nil, errors.New("pdfcrypt: Katalog (/Root) nicht gefunden")
+	}
+	return doc, trailer, nil
+}
+
+// objectBody liefert den Inhalt von Objekt n, das am Anfang von b steht.
+// Das Ende wird per Tokenizer gesucht, damit "endobj" in Strings oder Streams nicht stört.
+func objectBody(b []byte, n int) ([]byte, error) {
+	m := reObjHeader.FindSubmatchIndex(b)
+	if m == nil || string(b[m[2]:m[3]]) != strconv.Itoa(n) {
+		return nil, fmt.Errorf("pdfcrypt: Objekt %d nicht am angegebenen Offset", n)
+	}
+	start := m[1]
+	var end int
+	err := scan(b[start:], func(tok token) bool {
+		if tok.kind == tokKeyword && string(tok.raw) == "endobj" {
+			end = start + tok.pos
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pdfcrypt: Objekt %d: %w", n, err)
+	}
+	if end == 0 {
+		return nil, fmt.Errorf("pdfcrypt: Objekt %d ohne endobj", n)
+	}
+	return bytes.TrimSpace(b[start:end]), nil
+}
+
+// write setzt das Dokument mit verschlüsselten Strings und Streams neu zusammen
+// und hängt das Verschlüsselungs-Dictionary an.
+func (d *document) write(key []byte, encryptDict string) ([]byte, error) {
+	nums := make([]int, 0, len(d.objects))
+	max := 0
+	for n := range d.objects {
+		nums = append(nums, n)
+		if n > max {
+			max = n
+		}
+	}
+	sort.Ints(nums)
+	encNum := max + 1
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, encNum+1)
+	for _, n := range nums {
+		body, err := encryptBody(d.objects[n], key)
+		if err != nil {
+			return nil, fmt.Errorf("pdfcrypt: Objekt %d: %w", n, err)
+		}
+		if n == d.root {
+			// AES-256 (R6) ist für PDF 1.7 als Adobe-Erweiterung Level 8 definiert
+			body = bytes.Replace(body, []byte("<<"), []byte("<<\n/Extensions << /ADBE << /BaseVersion /1.7 /ExtensionLevel 8 >> >>"), 1)
+		}
+		offsets[n] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", n)
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+	offsets[encNum] = out.Len()
+	fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", encNum, encryptDict)
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", encNum+1)
+	for n := 1; n <= encNum; n++ {
+		if offsets[n] == 0 {
+			out.WriteString("0000000000 65535 f \n")
+			continue
+		}
+		fmt.Fprintf(&out, "%010d 00000 n \n", offsets[n])
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&out, "trailer\n<<\n/Size %d\n/Root %d 0 R\n", encNum+1, d.root)
+	if d.info != 0 {
+		fmt.Fprintf(&out, "/Info %d 0 R\n", d.info)
+	}
+	fmt.Fprintf(&out, "/Encrypt %d 0 R\n/ID [<%x> <%x>]\n>>\nstartxref\n%d\n%%%%EOF\n", encNum, id, id, xref)
+	return out.Bytes(), nil
+}
+
+// encryptBody ersetzt alle Strings eines Objekts durch verschlüsselte Hex-Strings
+// und verschlüsselt einen vorhandenen Stream samt angepasster /Length.
+func encryptBody(body, key []byte) ([]byte, error) {
+	var out bytes.Buffer
+	last := 0
+	var stream *token
+	var encErr error
+	err := scan(body, func(tok token) bool {
+		switch tok.kind {
+		case tokString:
+			enc, err := encryptAES(tok.value, key)
+			if err != nil {
+				encErr = err
+				return false
+			}
+			out.Write(body[last:tok.pos])
+			out.WriteString("<" + hex.EncodeToString(enc) + ">")
+			last = tok.pos + len(tok.raw)
+		case tokStream:
+			stream = &tok
+			return false
+		}
+		return true
+	})
+	if err == nil {
+		err = encErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stream == nil {
+		out.Write(body[last:])
+		return out.Bytes(), nil
+	}
+
+	out.Write(body[last:stream.pos])
+	dict := out.Bytes()
+	all := reLength.FindAllSubmatchIndex(dict, -1)
+	if len(all) == 0 {
+		return nil, errors.New("Stream ohne direkte /Length")
+	}
+	m := all[len(all)-1]
+	enc, err := encryptAES(stream.value, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var res bytes.Buffer
+	res.Write(dict[:m[4]])
+	res.WriteString(strconv.Itoa(len(enc)))
+	res.Write(dict[m[5]:])
+	res.WriteString("stream\n")
+	res.Write(enc)
+	res.WriteString("\nendstream")
+	return res.Bytes(), nil
+}
+
+// encryptAES verschlüsselt nach AESV3: AES-256-CBC, zufälliger IV vorangestellt, PKCS#7-Padding.
+func encryptAES(plain, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	buf := make([]byte, aes.BlockSize+len(plain)+pad)
+	if _, err := rand.Read(buf[:aes.BlockSize]); err != nil {
+		return nil, err
+	}
+	copy(buf[aes.BlockSize:], plain)
+	for i := len(buf) - pad; i < len(buf); i++ {
+		buf[i] = byte(pad)
+	}
+	cipher.NewCBCEncrypter(block, buf[:aes.BlockSize]).CryptBlocks(buf[aes.BlockSize:], buf[aes.BlockSize:])
+	return buf, nil
+}
@@ -0,0 +1,136 @@
+package pdfcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"errors"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const attachment = "Geheimer Inhalt der Anlage"
+
+// samplePDF erzeugt ein kleines unverschlüsseltes PDF mit Text und eingebetteter Datei.
+func samplePDF(t *testing.T) []byte {
+	t.Helper()
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Testdokument", false)
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.Cell(0, 10, "Hallo Welt")
+	pdf.SetAttachments([]gofpdf.Attachment{{Content: []byte(attachment), Filename: "anlage.txt"}})
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// encryptDict liefert das /Encrypt-Dictionary eines verschlüsselten PDFs.
+func encryptDict(t *testing.T, out []byte) []byte {
+	t.Helper()
+	doc, trailer, err := readObjects(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := reEncrypt.FindSubmatch(trailer)
+	if m == nil {
+		t.Fatalf("Trailer ohne /Encrypt: %s", trailer)
+	}
+	n, _ := strconv.Atoi(string(m[1]))
+	return doc.objects[n]
+}
+
+func TestEncryptDictionary(t *testing.T) {
+	out, err := Encrypt(samplePDF(t), Options{
+		UserPassword:  []byte("benutzer"),
+		OwnerPassword: []byte("eigentümer"),
+		Permissions:   PermPrint,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict := encryptDict(t, out)
+	for _, want := range []string{`/Filter\s*/Standard`, `/V\s+5\b`, `/R\s+6\b`, `/Length\s+256\b`, `/CFM\s*/AESV3`, `/StmF\s*/StdCF`, `/StrF\s*/StdCF`} {
+		if !regexp.MustCompile(want).Match(dict) {
+			t.Errorf("/Encrypt ohne %s: %s", want, dict)
+		}
+	}
+	for name, size := range map[string]int{"U": 48, "UE": 32, "O": 48, "OE": 32, "Perms": 16} {
+		if got := len(dictString(dict, name)); got != size {
+			t.Errorf("/%s hat %d Bytes, erwartet %d", name, got, size)
+		}
+	}
+
+	// /Perms muss mit dem Dateischlüssel zu /P und "Tadb" entschlüsseln
+	key, err := standardKey(dict, []byte("benutzer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	perms := make([]byte, 16)
+	c.Decrypt(perms, dictString(dict, "Perms"))
+	if string(perms[8:12]) != "Tadb" {
+		t.Errorf("/Perms entschlüsselt zu %x", perms)
+	}
+	m := regexp.MustCompile(`/P\s+(-?\d+)`).FindSubmatch(dict)
+	if m == nil {
+		t.Fatal("/Encrypt ohne /P")
+	}
+	p, _ := strconv.ParseInt(string(m[1]), 10, 32)
+	if got := int32(binary.LittleEndian.Uint32(perms[:4])); got != int32(p) || int32(p) != permissionBits(PermPrint) {
+		t.Errorf("/P %d, in /Perms %d, erwartet %d", p, got, permissionBits(PermPrint))
+	}
+
+	if bytes.Contains(out, []byte(attachment)) || bytes.Contains(out, []byte("Testdokument")) {
+		t.Error("Klartext im verschlüsselten PDF")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	out, err := Encrypt(samplePDF(t), Options{
+		UserPassword:  []byte("benutzer"),
+		OwnerPassword: []byte("eigentümer"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pw := range []string{"benutzer", "eigentümer"} {
+		files, err := EmbeddedFiles(out, []byte(pw))
+		if err != nil {
+			t.Fatalf("%s: %v", pw, err)
+		}
+		if got := string(files["anlage.txt"]); got != attachment {
+			t.Errorf("%s: Anlage %q, erwartet %q", pw, got, attachment)
+		}
+	}
+	if _, err := EmbeddedFiles(out, []byte("falsch")); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("falsches Passwort: %v, erwartet ErrWrongPassword", err)
+	}
+}
+
+func TestOwnerDefaultsToUser(t *testing.T) {
+	out, err := Encrypt(samplePDF(t), Options{UserPassword: []byte("benutzer")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict := encryptDict(t, out)
+	o := dictString(dict, "O")
+	// Ohne Eigentümerpasswort öffnet das Benutzerpasswort auch über /O
+	if !bytes.Equal(hash2B([]byte("benutzer"), o[32:40], dictString(dict, "U")[:48]), o[:32]) {
+		t.Error("/O passt nicht zum Benutzerpasswort")
+	}
+}
+
+func TestEncryptRejectsEmptyPassword(t *testing.T) {
+	if _, err := Encrypt(samplePDF(t), Options{}); err == nil {
+		t.Error("leeres Benutzerpasswort akzeptiert")
+	}
+}
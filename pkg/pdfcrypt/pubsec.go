@@ -0,0 +1,181 @@
+package pdfcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidAES256CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// PKCS#7/CMS-Strukturen (RFC 5652), soweit für EnvelopedData mit RSA-Empfängern nötig.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue // [0] EXPLICIT, von Hand gesetzt
+}
+
+type envelopedData struct {
+	Version              int
+	RecipientInfos       []recipientInfo `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type recipientInfo struct {
+	Version                int
+	IssuerAndSerialNumber  issuerAndSerial
+	KeyEncryptionAlgorithm algorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type issuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm algorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0"`
+}
+
+// pubSecHandler implementiert den Public-Key-Security-Handler (adbe.pkcs7.s5, AES-256):
+// Ein zufälliger Seed wird für jeden Empfänger mit dessen RSA-Schlüssel verpackt, der
+// Dateischlüssel ist SHA-256 über Seed und Empfängerliste (ISO 32000-2, 7.6.5.3).
+type pubSecHandler struct {
+	fileKey    []byte
+	recipients []byte // DER-kodiertes PKCS#7 für alle Empfänger
+}
+
+func newPubSecHandler(certs []*x509.Certificate, perms Permission) (*pubSecHandler, error) {
+	// 20 Bytes Seed plus die Rechte (big-endian) bilden den verschlüsselten Inhalt
+	content := make([]byte, 24)
+	if _, err := rand.Read(content[:20]); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(content[20:], uint32(permissionBits(perms)))
+
+	der, err := envelope(content, certs)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.New()
+	sum.Write(content[:20])
+	sum.Write(der)
+	return &pubSecHandler{fileKey: sum.Sum(nil), recipients: der}, nil
+}
+
+func (h *pubSecHandler) key() []byte { return h.fileKey }
+
+func (h *pubSecHandler) dict() string {
+	return fmt.Sprintf("<<\n/Filter /Adobe.PubSec\n/SubFilter /adbe.pkcs7.s5\n/V 5\n/Length 256\n"+
+		"/CF << /DefaultCryptFilter << /Type /CryptFilter /CFM /AESV3 /AuthEvent /DocOpen /Length 32\n"+
+		"/Recipients [<%s>] /EncryptMetadata true >> >>\n"+
+		"/StmF /DefaultCryptFilter\n/StrF /DefaultCryptFilter\n>>", hex.EncodeToString(h.recipients))
+}
+
+// envelope verschlüsselt content mit AES-256-CBC und verpackt den Inhaltsschlüssel
+// für jedes Zertifikat per RSA PKCS#1 v1.5 in einer gemeinsamen EnvelopedData.
+func envelope(content []byte, certs []*x509.Certificate) ([]byte, error) {
+	cek := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(cek); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	pad := aes.BlockSize - len(content)%aes.BlockSize
+	enc := append(append([]byte{}, content...), make([]byte, pad)...)
+	for i := len(content); i < len(enc); i++ {
+		enc[i] = byte(pad)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(enc, enc)
+
+	ed := envelopedData{}
+	for _, c := range certs {
+		pub, ok := c.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("pdfcrypt: Zertifikat %q hat keinen RSA-Schlüssel", c.Subject.CommonName)
+		}
+		key, err := rsa.EncryptPKCS1v15(rand.Reader, pub, cek)
+		if err != nil {
+			return nil, err
+		}
+		ed.RecipientInfos = append(ed.RecipientInfos, recipientInfo{
+			IssuerAndSerialNumber:  issuerAndSerial{Issuer: asn1.RawValue{FullBytes: c.RawIssuer}, SerialNumber: c.SerialNumber},
+			KeyEncryptionAlgorithm: algorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+			EncryptedKey:           key,
+		})
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	ed.EncryptedContentInfo = encryptedContentInfo{
+		ContentType:                oidData,
+		ContentEncryptionAlgorithm: algorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+		EncryptedContent:           enc,
+	}
+	edDER, err := asn1.Marshal(ed)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidEnvelopedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: edDER},
+	})
+}
+
+// ReadCertificates liest alle X.509-Zertifikate aus einer PEM-Datei.
+func ReadCertificates(path string) ([]*x509.Certificate, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for {
+		var b *pem.Block
+		b, raw = pem.Decode(raw)
+		if b == nil {
+			break
+		}
+		if !strings.EqualFold(b.Type, "CERTIFICATE") {
+			continue
+		}
+		c, err := x509.ParseCertificate(b.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New(path + ": kein PEM-Zertifikat gefunden")
+	}
+	return certs, nil
+}
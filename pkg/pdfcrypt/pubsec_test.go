@@ -0,0 +1,168 @@
+package pdfcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testRecipient erzeugt einen Wegwerf-Schlüssel mit selbstsigniertem Zertifikat.
+func testRecipient(t *testing.T, name string, serial int64) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, cert
+}
+
+// openEnvelope entschlüsselt die EnvelopedData aus /Recipients mit dem Schlüssel von cert
+// und liefert den Inhalt (Seed und Rechte).
+func openEnvelope(t *testing.T, der []byte, key *rsa.PrivateKey, cert *x509.Certificate) []byte {
+	t.Helper()
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil || len(rest) > 0 {
+		t.Fatalf("ContentInfo: %v (%d Bytes übrig)", err, len(rest))
+	}
+	if !ci.ContentType.Equal(oidEnvelopedData) {
+		t.Fatalf("ContentType %v, erwartet EnvelopedData", ci.ContentType)
+	}
+	var ed envelopedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+		t.Fatal(err)
+	}
+	var cek []byte
+	for _, ri := range ed.RecipientInfos {
+		if bytes.Equal(ri.IssuerAndSerialNumber.Issuer.FullBytes, cert.RawIssuer) && ri.IssuerAndSerialNumber.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			if !ri.KeyEncryptionAlgorithm.Algorithm.Equal(oidRSAEncryption) {
+				t.Fatalf("Schlüsselverfahren %v", ri.KeyEncryptionAlgorithm.Algorithm)
+			}
+			var err error
+			if cek, err = rsa.DecryptPKCS1v15(nil, key, ri.EncryptedKey); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if cek == nil {
+		t.Fatalf("kein Empfänger für %s", cert.Subject.CommonName)
+	}
+	eci := ed.EncryptedContentInfo
+	if !eci.ContentEncryptionAlgorithm.Algorithm.Equal(oidAES256CBC) {
+		t.Fatalf("Inhaltsverfahren %v", eci.ContentEncryptionAlgorithm.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(eci.ContentEncryptionAlgorithm.Parameters.FullBytes, &iv); err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := make([]byte, len(eci.EncryptedContent))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, eci.EncryptedContent)
+	pad := int(plain[len(plain)-1])
+	if pad < 1 || pad > aes.BlockSize {
+		t.Fatalf("ungültiges Padding %d", pad)
+	}
+	return plain[:len(plain)-pad]
+}
+
+func TestPubSec(t *testing.T) {
+	keyA, certA := testRecipient(t, "Anna", 1)
+	keyB, certB := testRecipient(t, "Ben", 2)
+	out, err := Encrypt(samplePDF(t), Options{Recipients: []*x509.Certificate{certA, certB}, Permissions: PermPrint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict := encryptDict(t, out)
+	for _, want := range []string{`/Filter\s*/Adobe\.PubSec`, `/SubFilter\s*/adbe\.pkcs7\.s5`, `/V\s+5\b`, `/Length\s+256\b`, `/CFM\s*/AESV3`, `/StmF\s*/DefaultCryptFilter`, `/StrF\s*/DefaultCryptFilter`} {
+		if !regexp.MustCompile(want).Match(dict) {
+			t.Errorf("/Encrypt ohne %s: %s", want, dict)
+		}
+	}
+	m := regexp.MustCompile(`/Recipients\s*\[\s*<([0-9a-fA-F]+)>\s*\]`).FindSubmatch(dict)
+	if m == nil {
+		t.Fatalf("/Encrypt ohne /Recipients: %s", dict)
+	}
+	der, err := hex.DecodeString(string(m[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []struct {
+		key  *rsa.PrivateKey
+		cert *x509.Certificate
+	}{{keyA, certA}, {keyB, certB}} {
+		content := openEnvelope(t, der, r.key, r.cert)
+		if len(content) != 24 {
+			t.Fatalf("%s: Inhalt %d Bytes, erwartet 24", r.cert.Subject.CommonName, len(content))
+		}
+		if got := int32(binary.BigEndian.Uint32(content[20:])); got != permissionBits(PermPrint) {
+			t.Errorf("%s: Rechte %d, erwartet %d", r.cert.Subject.CommonName, got, permissionBits(PermPrint))
+		}
+		// Dateischlüssel nach ISO 32000-2, 7.6.5.3: SHA-256 über Seed und Empfängerliste
+		sum := sha256.New()
+		sum.Write(content[:20])
+		sum.Write(der)
+		key := sum.Sum(nil)
+
+		doc, _, err := readObjects(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found bool
+		for _, body := range doc.objects {
+			ef := reEF.FindSubmatch(body)
+			if ef == nil {
+				continue
+			}
+			n, _ := strconv.Atoi(string(ef[1]))
+			data, err := streamData(doc.objects[n], key)
+			if err != nil {
+				t.Fatalf("%s: %v", r.cert.Subject.CommonName, err)
+			}
+			found = string(data) == attachment
+		}
+		if !found {
+			t.Errorf("%s: Anlage mit abgeleitetem Schlüssel nicht lesbar", r.cert.Subject.CommonName)
+		}
+	}
+
+	if bytes.Contains(out, []byte(attachment)) {
+		t.Error("Klartext im verschlüsselten PDF")
+	}
+}
+
+func TestPubSecRejectsPassword(t *testing.T) {
+	_, cert := testRecipient(t, "Anna", 1)
+	if _, err := Encrypt(samplePDF(t), Options{Recipients: []*x509.Certificate{cert}, UserPassword: []byte("pw")}); err == nil {
+		t.Error("Passwort und Empfänger zugleich akzeptiert")
+	}
+}
@@ -0,0 +1,201 @@
+package pdfcrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+type tokKind int
+
+const (
+	tokString  tokKind = iota // Literal- oder Hex-String, value ist dekodiert
+	tokKeyword                // Zahl, Schlüsselwort wie "endobj" oder "R"
+	tokStream                 // "stream" samt Zeilenende, value sind die Stream-Daten
+)
+
+type token struct {
+	kind  tokKind
+	pos   int    // Offset in der gescannten Eingabe
+	raw   []byte // Token, wie es in der Eingabe steht
+	value []byte
+}
+
+func isWhite(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isDelim(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+// scan zerlegt b in die Tokens, die für die Verschlüsselung eine Rolle spielen: Strings,
+// Schlüsselwörter und Streams. Namen, Dictionary-Klammern und Kommentare werden übersprungen.
+// fn kann den Scan durch Rückgabe von false beenden.
+func scan(b []byte, fn func(token) bool) error {
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case isWhite(c):
+			i++
+		case c == '%':
+			for i < len(b) && b[i] != '\n' && b[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			val, n, err := literalString(b[i:])
+			if err != nil {
+				return err
+			}
+			if !fn(token{kind: tokString, pos: i, raw: b[i : i+n], value: val}) {
+				return nil
+			}
+			i += n
+		case c == '<' && i+1 < len(b) && b[i+1] == '<':
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(b[i:], '>')
+			if end < 0 {
+				return errors.New("Hex-String ohne Ende")
+			}
+			val, err := hexString(b[i+1 : i+end])
+			if err != nil {
+				return err
+			}
+			if !fn(token{kind: tokString, pos: i, raw: b[i : i+end+1], value: val}) {
+				return nil
+			}
+			i += end + 1
+		case c == '/':
+			i++
+			for i < len(b) && !isWhite(b[i]) && !isDelim(b[i]) {
+				i++
+			}
+		case isDelim(c):
+			i++
+		default:
+			j := i
+			for j < len(b) && !isWhite(b[j]) && !isDelim(b[j]) {
+				j++
+			}
+			word := b[i:j]
+			if string(word) != "stream" {
+				if !fn(token{kind: tokKeyword, pos: i, raw: word}) {
+					return nil
+				}
+				i = j
+				continue
+			}
+			// Stream-Daten beginnen nach CRLF oder LF; die Länge steht im Dictionary davor.
+			if bytes.HasPrefix(b[j:], []byte("\r\n")) {
+				j += 2
+			} else if j < len(b) && b[j] == '\n' {
+				j++
+			}
+			all := reLength.FindAllSubmatch(b[:i], -1)
+			if len(all) == 0 {
+				return errors.New("Stream ohne direkte /Length")
+			}
+			n, _ := strconv.Atoi(string(all[len(all)-1][2]))
+			if j+n > len(b) {
+				return errors.New("/Length über Dateiende hinaus")
+			}
+			if !fn(token{kind: tokStream, pos: i, raw: b[i:j], value: b[j : j+n]}) {
+				return nil
+			}
+			i = j + n
+			for i < len(b) && isWhite(b[i]) {
+				i++
+			}
+			if !bytes.HasPrefix(b[i:], []byte("endstream")) {
+				return errors.New("endstream fehlt")
+			}
+			i += len("endstream")
+		}
+	}
+	return nil
+}
+
+// literalString dekodiert einen (...)-String am Anfang von b und liefert die verbrauchte Länge.
+func literalString(b []byte) ([]byte, int, error) {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return out, i + 1, nil
+			}
+		case '\r':
+			// Zeilenenden im String werden als LF gelesen
+			if i+1 < len(b) && b[i+1] == '\n' {
+				i++
+			}
+			c = '\n'
+		case '\\':
+			i++
+			if i >= len(b) {
+				return nil, 0, errors.New("String ohne Ende")
+			}
+			switch e := b[i]; e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if i+1 < len(b) && b[i+1] == '\n' {
+					i++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					for k := 0; k < 3 && i < len(b) && b[i] >= '0' && b[i] <= '7'; k++ {
+						v = v*8 + int(b[i]-'0')
+						i++
+					}
+					i--
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return nil, 0, errors.New("String ohne Ende")
+}
+
+// hexString dekodiert den Inhalt von <...>; Leerraum wird ignoriert, eine fehlende Ziffer ist 0.
+func hexString(b []byte) ([]byte, error) {
+	digits := make([]byte, 0, len(b)+1)
+	for _, c := range b {
+		if !isWhite(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(out, digits); err != nil {
+		return nil, errors.New("ungültiger Hex-String")
+	}
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package pdfwriter
+
+import (
+	"fmt"
+
+	"github.com/example/onepw-pdf-export/pkg/audit"
+)
+
+// writeAudit hängt den Audit-Bericht als eigenen Anhang an. Er nennt nur Titel, Tresor
+// und Befund, nie ein Passwort, und bleibt damit auch bei geschwärzten Exporten unkritisch.
+func (w *writer) writeAudit(rep audit.Report) {
+	pdf := w.pdf
+	pdf.AddPage()
+	pdf.SetFontStyle("B")
+	pdf.SetFontSize(14)
+	pdf.CellFormat(0, 9, "Anhang: Passwort-Audit", "", 1, "", false, 0, "")
+
+	h := 5.5
+	pdf.SetFontStyle("")
+	pdf.SetFontSize(10)
+	pdf.MultiCell(0, h, rep.Summary(), "", "", false)
+	if len(rep.Findings) == 0 {
+		pdf.Ln(2)
+		pdf.CellFormat(0, h, "Keine Auffälligkeiten.", "", 1, "", false, 0, "")
+	}
+
+	for _, k := range audit.Kinds {
+		n := rep.Count(k)
+		if n == 0 {
+			continue
+		}
+		// Überschrift nicht ohne ersten Befund am Seitenende
+		if w.remaining() < 2+7+2*h {
+			pdf.AddPage()
+		}
+		pdf.Ln(2)
+		pdf.SetFontStyle("B")
+		pdf.SetFontSize(12)
+		pdf.CellFormat(0, 7, fmt.Sprintf("%s (%d)", k, n), "B", 1, "", false, 0, "")
+		pdf.SetFontSize(10)
+		for _, f := range rep.Findings {
+			if f.Kind != k {
+				continue
+			}
+			if w.remaining() < 2*h {
+				pdf.AddPage()
+			}
+			title := f.Title
+			if f.Vault != "" {
+				title += " · " + f.Vault
+			}
+			pdf.SetFontStyle("B")
+			pdf.CellFormat(0, h, title, "", 1, "", false, 0, "")
+			pdf.SetFontStyle("")
+			pdf.MultiCell(0, h, f.Detail, "", "", false)
+		}
+	}
+	pdf.SetFontSize(11)
+}
@@ -0,0 +1,102 @@
+package pdfwriter
+
+import (
+	"github.com/example/onepw-pdf-export/pkg/model"
+)
+
+// Kartenlayout (style: cards): die Items als gerahmte Karten in zwei Spalten, zeilenweise
+// von links nach rechts. Eine Karte wird nie geteilt; zwei nebeneinander sind so hoch wie
+// die höhere. Karten, die nicht auf eine Seite passen, werden wie im Blocklayout gesetzt.
+
+const (
+	cardGap = 4.0 // Abstand zwischen und unter den Karten
+	cardPad = 2.0 // Innenabstand
+)
+
+func (w *writer) writeCards(items []model.Item) {
+	pdf := w.pdf
+	lm, _, _, _ := pdf.GetMargins()
+	cw := (w.contentWidth() - cardGap) / 2
+	for i := 0; i < len(items); i += 2 {
+		pair := items[i:min(i+2, len(items))]
+		var h float64
+		for _, it := range pair {
+			h = max(h, w.cardHeight(it, cw))
+		}
+		if h > w.pageCapacity() {
+			for _, it := range pair {
+				w.writeItem(it)
+			}
+			continue
+		}
+		if h > w.remaining() {
+			pdf.AddPage()
+		}
+		y := pdf.GetY()
+		for j, it := range pair {
+			w.drawCard(it, lm+float64(j)*(cw+cardGap), y, cw, h)
+		}
+		pdf.SetXY(lm, y+h+cardGap)
+	}
+}
+
+// cardHeight misst eine Karte der Breite width samt Innenabstand.
+func (w *writer) cardHeight(it model.Item, width float64) float64 {
+	w.col = column{w: width - 2*cardPad}
+	defer func() { w.col = column{} }()
+	h := 2*cardPad + w.cardTitleHeight() + w.measure(w.itemRows(it))
+	if itemMeta(it) != "" {
+		h += w.cardMetaHeight()
+	}
+	return h
+}
+
+func (w *writer) cardTitleHeight() float64 { return mdLineHeight(w.tpl.Font.Size + 1) }
+func (w *writer) cardMetaHeight() float64  { return mdLineHeight(w.tpl.Font.Small) }
+
+// drawCard zeichnet eine Karte mit Rahmen der Höhe h an x, y.
+func (w *writer) drawCard(it model.Item, x, y, width, h float64) {
+	pdf := w.pdf
+	title, meta := displayTitle(it), itemMeta(it)
+	link := pdf.AddLink()
+	pdf.SetLink(link, y, pdf.PageNo())
+	w.index = append(w.index, indexEntry{Title: title, Page: pdf.PageNo(), Link: link})
+
+	pdf.SetDrawColor(170, 170, 170)
+	pdf.RoundedRect(x, y, width, h, 2, "1234", "D")
+	pdf.SetDrawColor(0, 0, 0)
+
+	inner := width - 2*cardPad
+	w.col = column{x: x + cardPad, w: inner}
+	pdf.SetXY(w.col.x, y+cardPad)
+	pdf.SetFont(w.family(), "B", w.tpl.Font.Size+1)
+	pdf.CellFormat(inner, w.cardTitleHeight(), w.fit(title, inner), "", 2, "", false, 0, "")
+	if meta != "" {
+		pdf.SetFont(w.family(), "", w.tpl.Font.Small)
+		pdf.CellFormat(inner, w.cardMetaHeight(), w.fit(meta, inner), "", 2, "", false, 0, "")
+	}
+	w.textFont()
+	w.drawRows(w.itemRows(it))
+	w.col = column{}
+}
+
+// fit kürzt s mit der aktuellen Schrift auf width und hängt dann "…" an.
+func (w *writer) fit(s string, width float64) string {
+	if w.pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	ell := w.ellipsis()
+	r := []rune(s)
+	for len(r) > 0 && w.pdf.GetStringWidth(string(r)+ell) > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + ell
+}
+
+// ellipsis ist "…", ohne UTF-8-Schrift "...".
+func (w *writer) ellipsis() string {
+	if w.utf8 {
+		return "…"
+	}
+	return "..."
+}
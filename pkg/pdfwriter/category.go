@@ -0,0 +1,215 @@
+package pdfwriter
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/secret"
+)
+
+// Kategorien mit eigener Darstellung.
+const (
+	catCard     = "card"
+	catIdentity = "identity"
+	catSSH      = "ssh"
+	catBank     = "bank"
+	catNote     = "note"
+)
+
+// categoryOf bildet die Schreibweisen von op (CREDIT_CARD), 1PUX (Kategorie-Codes wie "002")
+// und Klartextnamen auf die Kategorien mit eigener Darstellung ab; sonst "".
+func categoryOf(c string) string {
+	switch strings.ToLower(strings.NewReplacer("_", "", " ", "", "-", "").Replace(c)) {
+	case "creditcard", "002", "kreditkarte":
+		return catCard
+	case "identity", "004", "identität":
+		return catIdentity
+	case "sshkey", "114", "sshschlüssel":
+		return catSSH
+	case "bankaccount", "101", "bankkonto":
+		return catBank
+	case "securenote", "003", "sicherenotiz":
+		return catNote
+	}
+	return ""
+}
+
+// fieldSet greift über Aliasnamen auf die Zusatzfelder eines Items zu und merkt sich,
+// welche schon gezeigt wurden; der Rest erscheint danach wie bei allen Items.
+type fieldSet struct {
+	it   model.Item
+	used map[string]bool
+}
+
+// take liefert das erste noch nicht gezeigte Zusatzfeld, dessen Label einem der Namen entspricht.
+func (f *fieldSet) take(names ...string) (string, secret.Bytes) {
+	for _, n := range names {
+		for k, v := range f.it.RawFields {
+			if !f.used[k] && strings.EqualFold(strings.TrimSpace(k), n) {
+				f.used[k] = true
+				return k, v
+			}
+		}
+	}
+	return "", nil
+}
+
+// show gibt ein Zusatzfeld so aus, wie es gedruckt wird: geschwärzt, falls seine Klasse
+// geschwärzt wird, sonst mit format aufbereitet (nil: unverändert).
+func (w *writer) show(f *fieldSet, label string, v secret.Bytes, format func(string) string) string {
+	if len(v) == 0 {
+		return ""
+	}
+	if class := fieldClass(label, v, f.it.Concealed[label]); w.opt.Redact.Fields[class] != 0 {
+		return w.opt.Redact.apply(class, v)
+	}
+	if format == nil {
+		return string(v)
+	}
+	return format(string(v))
+}
+
+// categoryRows liefert die Zeilen der kategoriespezifischen Darstellung (nil für andere Kategorien).
+func (w *writer) categoryRows(f *fieldSet) []row {
+	var rows []row
+	add := func(label, v string, r row) {
+		if v != "" {
+			r.Label, r.Value = label, v
+			if r.Size == 0 {
+				r.Size = w.tpl.Font.Size
+			}
+			rows = append(rows, r)
+		}
+	}
+	field := func(label string, format func(string) string, r row, names ...string) {
+		k, v := f.take(names...)
+		if class := fieldClass(k, v, f.it.Concealed[k]); class != "" && w.secretVisible(class) {
+			r.Secret = true
+		}
+		add(label, w.show(f, k, v, format), r)
+	}
+
+	switch categoryOf(f.it.Category) {
+	case catCard:
+		box := row{Box: true}
+		field("Inhaber", nil, box, "cardholder name", "cardholder", "name on card", "karteninhaber", "inhaber")
+		field("Nummer", groupDigits, box, "number", "card number", "ccnum", "kartennummer", "nummer")
+		field("Gültig bis", formatExpiry, box, "expiry date", "expiry", "expires", "expiration date", "gültig bis", "ablaufdatum")
+		field("CVV", nil, box, "verification number", "cvv", "cvc", "security code", "prüfnummer")
+		field("PIN", nil, box, "pin")
+		field("Typ", nil, box, "type", "kartentyp")
+		field("Aussteller", nil, box, "issuing bank", "bank", "aussteller")
+
+	case catIdentity:
+		var name []string
+		for _, names := range [][]string{{"first name", "vorname"}, {"initial"}, {"last name", "nachname"}} {
+			if k, v := f.take(names...); len(v) > 0 {
+				name = append(name, w.show(f, k, v, nil))
+			}
+		}
+		add("Name", strings.Join(name, " "), row{})
+		field("Adresse", formatAddress, row{}, "address", "adresse", "anschrift")
+		field("Geburtsdatum", formatDate, row{}, "birth date", "date of birth", "geburtsdatum")
+		field("Telefon", nil, row{}, "default phone", "phone", "telefon")
+		field("Mobil", nil, row{}, "cell", "mobile", "mobil")
+		field("E-Mail", nil, row{}, "email", "e-mail")
+		field("Firma", nil, row{}, "company", "firma")
+		field("Beruf", nil, row{}, "occupation", "job title", "beruf")
+
+	case catSSH:
+		_, pub := f.take("public key", "öffentlicher schlüssel")
+		field("Schlüsseltyp", nil, row{}, "key type", "schlüsseltyp")
+		fk, fp := f.take("fingerprint")
+		if len(fp) == 0 && len(pub) > 0 {
+			// op liefert den Fingerprint meist mit, sonst aus dem öffentlichen Schlüssel
+			if key, _, _, _, err := ssh.ParseAuthorizedKey(pub); err == nil {
+				fp = secret.New(ssh.FingerprintSHA256(key))
+			}
+		}
+		add("Fingerprint", w.show(f, fk, fp, nil), row{})
+		add("Public Key", string(pub), row{Mono: true, Size: 8})
+		field("Private Key", nil, row{Mono: true, Size: 8}, "private key", "privater schlüssel")
+
+	case catBank:
+		field("Bank", nil, row{}, "bank name", "bank", "kreditinstitut")
+		field("Inhaber", nil, row{}, "name on account", "account holder", "kontoinhaber", "inhaber")
+		field("Kontotyp", nil, row{}, "type", "kontotyp")
+		field("IBAN", formatIBAN, row{Mono: true}, "iban")
+		field("BIC", nil, row{Mono: true}, "swift", "bic", "swift/bic")
+		field("Kontonummer", nil, row{}, "account number", "kontonummer")
+		field("BLZ", nil, row{}, "routing number", "bankleitzahl", "blz")
+		field("PIN", nil, row{}, "pin")
+	}
+	return rows
+}
+
+// groupDigits schreibt eine Kartennummer in Vierergruppen.
+func groupDigits(s string) string {
+	var digits []rune
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		} else if r != ' ' && r != '-' {
+			return s
+		}
+	}
+	return groups(string(digits), 4)
+}
+
+// formatIBAN schreibt eine IBAN in Großbuchstaben und Vierergruppen.
+func formatIBAN(s string) string {
+	return groups(strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s)), 4)
+}
+
+func groups(s string, n int) string {
+	var b strings.Builder
+	for i, r := range []rune(s) {
+		if i > 0 && i%n == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// formatExpiry macht aus dem Monat/Jahr-Wert von op ("202612") "12/2026".
+func formatExpiry(s string) string {
+	if len(s) == 6 && strings.Trim(s, "0123456789") == "" {
+		return s[4:] + "/" + s[:4]
+	}
+	return s
+}
+
+// formatDate macht aus einem Unix-Zeitstempel (Datumsfelder von op) ein Datum.
+func formatDate(s string) string {
+	if sec, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Unix(int64(sec), 0).UTC().Format("2006-01-02")
+	}
+	return s
+}
+
+// formatAddress setzt eine Adresse von op ({"street":…,"city":…}) als Anschriftenblock.
+func formatAddress(s string) string {
+	var a struct {
+		Street  string `json:"street"`
+		City    string `json:"city"`
+		State   string `json:"state"`
+		Zip     string `json:"zip"`
+		Country string `json:"country"`
+	}
+	if json.Unmarshal([]byte(s), &a) != nil {
+		return s
+	}
+	var lines []string
+	for _, l := range []string{a.Street, strings.TrimSpace(a.Zip + " " + a.City), a.State, strings.ToUpper(a.Country)} {
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
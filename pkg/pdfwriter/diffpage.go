@@ -0,0 +1,101 @@
+package pdfwriter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/example/onepw-pdf-export/pkg/diff"
+	"github.com/example/onepw-pdf-export/pkg/model"
+)
+
+// WriteDiff schreibt einen Änderungsbericht zwischen zwei Ständen als verschlüsseltes PDF.
+// Neue Items erscheinen vollständig (mit opt.Redact), entfernte nur mit Titel, geänderte
+// mit ihren geänderten Feldern. from und to beschreiben die beiden Quellen im Seitenkopf.
+func WriteDiff(path string, res diff.Result, from, to string, opt Options) error {
+	now := time.Now()
+	var all []model.Item
+	all = append(all, res.Added...)
+	all = append(all, res.Removed...)
+	w, err := newWriter(opt, fmt.Sprintf("Vergleich: %s → %s · %s", from, to, now.Format("2006-01-02 15:04 MST")), fingerprint(now, all))
+	if err != nil {
+		return err
+	}
+	pdf := w.pdf
+	pdf.AddPage()
+	pdf.SetFontStyle("B")
+	pdf.SetFontSize(14)
+	pdf.CellFormat(0, 9, "Änderungen", "", 1, "", false, 0, "")
+	pdf.SetFontStyle("")
+	pdf.SetFontSize(10)
+	pdf.CellFormat(0, 5.5, fmt.Sprintf("Neu: %d · Entfernt: %d · Geändert: %d", len(res.Added), len(res.Removed), len(res.Modified)), "", 1, "", false, 0, "")
+	pdf.SetFontSize(11)
+	pdf.Ln(3)
+	if res.Empty() {
+		pdf.CellFormat(0, 6, "Keine Änderungen.", "", 1, "", false, 0, "")
+		return w.save(path)
+	}
+
+	if len(res.Added) > 0 {
+		w.writeGroupHeading("Neu", len(res.Added))
+		for _, it := range res.Added {
+			w.writeItem(it)
+		}
+	}
+	if len(res.Modified) > 0 {
+		w.writeGroupHeading("Geändert", len(res.Modified))
+		for _, c := range res.Modified {
+			w.writeChange(c)
+		}
+	}
+	if len(res.Removed) > 0 {
+		w.writeGroupHeading("Entfernt", len(res.Removed))
+		pdf.SetFontSize(10)
+		for _, it := range res.Removed {
+			line := displayTitle(it)
+			if it.Vault != "" {
+				line += " · " + it.Vault
+			}
+			pdf.CellFormat(0, 5.5, line, "", 1, "", false, 0, "")
+		}
+		pdf.SetFontSize(11)
+	}
+	return w.save(path)
+}
+
+// writeChange zeichnet ein geändertes Item mit einer Zeile "alt → neu" je Feld.
+func (w *writer) writeChange(c diff.Change) {
+	pdf := w.pdf
+	rows := make([]row, 0, len(c.Fields))
+	for _, f := range c.Fields {
+		rows = append(rows, row{Label: f.Field, Value: orEmpty(f.Old) + " → " + orEmpty(f.New), Size: 11})
+	}
+	height := titleHeight + w.measure(rows) + itemGap
+	if c.Vault != "" {
+		height += metaHeight
+	}
+	if height > w.remaining() && (height <= w.pageCapacity() || w.remaining() < minSplitSpace) {
+		pdf.AddPage()
+	}
+
+	pdf.SetFontStyle("B")
+	pdf.CellFormat(0, titleHeight, c.Title, "", 1, "", false, 0, "")
+	pdf.SetFontStyle("")
+	if c.Vault != "" {
+		pdf.SetFontSize(10)
+		pdf.CellFormat(0, metaHeight, c.Vault, "", 1, "", false, 0, "")
+		pdf.SetFontSize(11)
+	}
+	w.cont = c.Title
+	for _, r := range rows {
+		w.drawRow(r)
+	}
+	w.cont = ""
+	pdf.Ln(itemGap)
+}
+
+func orEmpty(s string) string {
+	if s == "" {
+		return "(leer)"
+	}
+	return s
+}
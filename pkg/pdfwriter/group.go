@@ -0,0 +1,117 @@
+package pdfwriter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+)
+
+// GroupKeys listet die unterstützten Werte für Options.GroupBy.
+var GroupKeys = []string{"vault", "category", "tag"}
+
+type group struct {
+	Name  string
+	Items []model.Item
+	// ids sind die Positionen der Items in der Eingabe, refs die von Items, die schon in
+	// einer früheren Gruppe gedruckt sind und hier nur verwiesen werden (nur bei "tag").
+	ids, refs []int
+}
+
+// groupItems teilt items nach by auf. Die Reihenfolge innerhalb einer Gruppe bleibt erhalten,
+// die Gruppen selbst sind alphabetisch sortiert; Items ohne Wert landen in einer Sammelgruppe am Ende.
+// Bei "tag" wird ein Item mit mehreren Tags nur in der ersten seiner Gruppen gedruckt und
+// in den übrigen verwiesen, damit kein Geheimnis mehrfach auf Papier steht.
+func groupItems(items []model.Item, by string) ([]group, error) {
+	var keys func(model.Item) []string
+	var empty string
+	switch strings.ToLower(strings.TrimSpace(by)) {
+	case "":
+		return []group{{Items: items}}, nil
+	case "vault":
+		keys = func(it model.Item) []string { return []string{it.Vault} }
+		empty = "(ohne Tresor)"
+	case "category":
+		keys = func(it model.Item) []string { return []string{it.Category} }
+		empty = "(ohne Kategorie)"
+	case "tag":
+		keys = func(it model.Item) []string { return it.Tags }
+		empty = "(ohne Tag)"
+	default:
+		return nil, fmt.Errorf("pdfwriter: unbekannte Gruppierung %q (erlaubt: %s)", by, strings.Join(GroupKeys, "|"))
+	}
+
+	byName := map[string][]int{}
+	var order []string
+	for i, it := range items {
+		names := map[string]bool{}
+		for _, k := range keys(it) {
+			if k = strings.TrimSpace(k); k != "" {
+				names[k] = true
+			}
+		}
+		if len(names) == 0 {
+			names[empty] = true
+		}
+		for _, name := range sortedKeys(names) {
+			if _, ok := byName[name]; !ok {
+				order = append(order, name)
+			}
+			byName[name] = append(byName[name], i)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if (order[i] == empty) != (order[j] == empty) {
+			return order[j] == empty
+		}
+		return strings.ToLower(order[i]) < strings.ToLower(order[j])
+	})
+
+	out := make([]group, 0, len(order))
+	printed := map[int]bool{}
+	for _, name := range order {
+		g := group{Name: name}
+		for _, i := range byName[name] {
+			if printed[i] {
+				g.refs = append(g.refs, i)
+				continue
+			}
+			printed[i] = true
+			g.Items = append(g.Items, items[i])
+			g.ids = append(g.ids, i)
+		}
+		out = append(out, g)
+	}
+	return out, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// writeRefs verweist auf Items der Gruppe, die bereits in einer früheren Gruppe stehen.
+func (w *writer) writeRefs(refs []indexEntry) {
+	if len(refs) == 0 {
+		return
+	}
+	pdf := w.pdf
+	h := 5.5
+	if w.remaining() < 6+h {
+		pdf.AddPage()
+	}
+	pdf.SetFont(w.family(), "I", 10)
+	pdf.CellFormat(0, 6, "Ebenfalls in dieser Gruppe, bereits weiter vorne gedruckt:", "", 1, "", false, 0, "")
+	pdf.SetFont(w.family(), "", 10)
+	for _, e := range refs {
+		pdf.CellFormat(w.contentWidth()-15, h, e.Title, "", 0, "", false, e.Link, "")
+		pdf.CellFormat(15, h, fmt.Sprintf("S. %d", e.Page), "", 1, "R", false, e.Link, "")
+	}
+	w.textFont()
+	pdf.Ln(itemGap)
+}
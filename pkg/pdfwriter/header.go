@@ -0,0 +1,69 @@
+package pdfwriter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+)
+
+// pageCountAlias wird von gofpdf beim Schließen durch die Gesamtseitenzahl ersetzt.
+const pageCountAlias = "{nb}"
+
+// header läuft bei jedem Seitenwechsel, auch bei automatischen Umbrüchen mitten in einem Item.
+func (w *writer) header() {
+	pdf := w.pdf
+	w.watermark()
+	lm, tm, _, _ := pdf.GetMargins()
+	w.banner(tm - 7)
+	pdf.SetXY(lm, tm)
+
+	label := w.opt.Label
+	if label == "" {
+		label = "1Password Export"
+	}
+	pdf.SetFont("", "B", 8)
+	pdf.CellFormat(w.contentWidth()/2, 4, label, "", 0, "L", false, 0, "")
+	pdf.SetFont("", "", 8)
+	pdf.CellFormat(w.contentWidth()/2, 4, w.meta, "", 1, "R", false, 0, "")
+	x, y := pdf.GetX(), pdf.GetY()+1
+	pdf.Line(x, y, x+w.contentWidth(), y)
+	pdf.Ln(5)
+
+	if w.cont != "" {
+		pdf.SetFont("", "I", 9)
+		pdf.CellFormat(0, 5, w.cont+" (Fortsetzung)", "", 1, "", false, 0, "")
+		pdf.Ln(1)
+	}
+	pdf.SetFont("", "", 11)
+	if w.thead != nil {
+		w.thead()
+	}
+	w.top = pdf.GetY()
+}
+
+// footer druckt Fingerprint und "Seite X von Y" auf jede Seite.
+func (w *writer) footer() {
+	pdf := w.pdf
+	_, ph := pdf.GetPageSize()
+	w.banner(ph - 7)
+	pdf.SetY(-12)
+	pdf.SetFont("", "", 8)
+	pdf.CellFormat(w.contentWidth()/2, 4, "Fingerprint: "+w.fp, "T", 0, "L", false, 0, "")
+	pdf.CellFormat(w.contentWidth()/2, 4, fmt.Sprintf("Seite %d von %s", pdf.PageNo(), pageCountAlias), "T", 0, "R", false, 0, "")
+}
+
+// fingerprint kennzeichnet einen Export eindeutig, damit ausgedruckte Seiten demselben
+// Dokument zugeordnet werden können. Geheimnisse fließen bewusst nicht ein.
+func fingerprint(at time.Time, items []model.Item) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", at.UTC().Format(time.RFC3339Nano))
+	for _, it := range items {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\n", it.Title, it.Vault, it.Category, it.Username, strings.Join(it.URLs, " "))
+	}
+	sum := hex.EncodeToString(h.Sum(nil))[:16]
+	return strings.ToUpper(sum[0:4] + "-" + sum[4:8] + "-" + sum[8:12] + "-" + sum[12:16])
+}
@@ -0,0 +1,81 @@
+package pdfwriter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// indexEntry merkt sich, auf welcher Seite ein Item gelandet ist.
+type indexEntry struct {
+	Title string
+	Page  int
+	Link  int
+}
+
+// indexLetter liefert den Buchstaben, unter dem title im Index einsortiert wird.
+// Umlaute werden ihrem Grundbuchstaben zugeordnet, alles außer A–Z landet unter "#".
+func indexLetter(title string) string {
+	for _, r := range title {
+		r = unicode.ToUpper(r)
+		switch r {
+		case 'Ä':
+			r = 'A'
+		case 'Ö':
+			r = 'O'
+		case 'Ü':
+			r = 'U'
+		}
+		if r >= 'A' && r <= 'Z' {
+			return string(r)
+		}
+		return "#"
+	}
+	return "#"
+}
+
+// writeIndex hängt ein alphabetisches Verzeichnis aller Titel mit Seitenzahlen an.
+// Jeder Eintrag verlinkt auf die Stelle des Items im Dokument.
+func (w *writer) writeIndex() {
+	if len(w.index) == 0 {
+		return
+	}
+	pdf := w.pdf
+	sorted := make([]indexEntry, len(w.index))
+	copy(sorted, w.index)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		li, lj := indexLetter(sorted[i].Title), indexLetter(sorted[j].Title)
+		if li != lj {
+			// "#" vor A–Z
+			return li < lj
+		}
+		return strings.ToLower(sorted[i].Title) < strings.ToLower(sorted[j].Title)
+	})
+
+	pdf.AddPage()
+	pdf.SetFontStyle("B")
+	pdf.SetFontSize(14)
+	pdf.CellFormat(0, 9, "Index", "", 1, "", false, 0, "")
+
+	h := 5.5
+	letter := ""
+	for _, e := range sorted {
+		if l := indexLetter(e.Title); l != letter {
+			letter = l
+			// Buchstabe nicht ohne ersten Eintrag am Seitenende
+			if w.remaining() < 2+7+h {
+				pdf.AddPage()
+			}
+			pdf.Ln(2)
+			pdf.SetFontStyle("B")
+			pdf.SetFontSize(12)
+			pdf.CellFormat(0, 7, letter, "B", 1, "", false, 0, "")
+		}
+		pdf.SetFontStyle("")
+		pdf.SetFontSize(10)
+		pdf.CellFormat(w.contentWidth()-15, h, e.Title, "", 0, "", false, e.Link, "")
+		pdf.CellFormat(15, h, fmt.Sprintf("%d", e.Page), "", 1, "R", false, e.Link, "")
+	}
+	pdf.SetFontSize(11)
+}
@@ -0,0 +1,353 @@
+package pdfwriter
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/pwhash"
+)
+
+// Maße in mm. Seitenformat, Ränder und Labelspalte kommen aus der Vorlage.
+const (
+	lineHeight  = 6.0
+	titleHeight = 7.0
+	metaHeight  = 5.0
+	itemGap     = 2.0
+	// minSplitSpace ist der Mindestplatz, ab dem ein überlanges Item noch auf der
+	// aktuellen Seite beginnt, statt direkt auf die nächste zu wandern.
+	minSplitSpace = 40.0
+	// cardWidth ist die Breite des Rahmens um Kartendaten (etwa 1,3-fache Scheckkarte).
+	cardWidth = 112.0
+)
+
+// row ist eine Label/Wert-Zeile eines Items; Size ist die Schriftgröße des Werts.
+type row struct {
+	Label    string
+	Value    string
+	Size     float64
+	Mono     bool // Wert in Festbreitenschrift, z. B. Schlüssel
+	Secret   bool // Geheimnis: Festbreitenschrift, mit Options.PasswordColors nach Zeichenklassen gefärbt
+	Wide     bool // Wert über die ganze Breite ohne Label (sichere Notizen)
+	Box      bool // aufeinanderfolgende Box-Zeilen werden eingerahmt (Karten)
+	Markdown bool // Wert als Markdown setzen (Notizen)
+}
+
+// itemRows legt fest, welche Felder ein Item in der gewählten Vorlage zeigt, in deren
+// Reihenfolge. Messen und Zeichnen arbeiten beide auf diesen Zeilen, damit die Höhen übereinstimmen.
+func (w *writer) itemRows(it model.Item) []row {
+	opt := w.opt
+	var rows []row
+	kv := func(k, v string, size float64) {
+		if v == "" {
+			return
+		}
+		rows = append(rows, row{Label: k, Value: v, Size: size})
+	}
+
+	// die kategoriespezifische Darstellung belegt ihre Zusatzfelder auch dann, wenn sie
+	// erst nach "fields" steht, damit nichts doppelt erscheint; ohne "category" in der
+	// Vorlage erscheinen sie alle unter "fields"
+	f := &fieldSet{it: it, used: map[string]bool{}}
+	var category []row
+	if w.tpl.has("category") {
+		category = w.categoryRows(f)
+	}
+
+	for _, fd := range w.tpl.Fields {
+		label, size := fd.label(), w.tpl.size(fd)
+		switch fd.Name {
+		case "category":
+			rows = append(rows, category...)
+		case "password":
+			if w.pwfp != nil && len(it.Password) > 0 {
+				kv(label, pwhash.Summary(it.Password), size)
+				kv("PW-Fingerprint", w.pwfp.Fingerprint(it.Password), size)
+				continue
+			}
+			pw := w.fieldText(it, fd.Name)
+			if pw == "" {
+				continue
+			}
+			visible := w.secretVisible("password")
+			rows = append(rows, row{Label: label, Value: pw, Size: size, Secret: visible})
+			if visible && w.opt.PasswordSpelling {
+				rows = append(rows, row{Value: spell(pw), Size: w.tpl.Font.Small})
+			}
+		case "totp":
+			if v := w.fieldText(it, fd.Name); v != "" {
+				rows = append(rows, row{Label: label, Value: v, Size: size, Secret: w.secretVisible("totp")})
+			}
+		case "notes":
+			// Notizen sind in 1Password Markdown; geschwärzte Notizen bleiben Klartext
+			notes := w.fieldText(it, fd.Name)
+			if notes == "" {
+				continue
+			}
+			r := row{Label: label, Value: notes, Size: size, Markdown: opt.Redact.Fields["notes"] == 0}
+			if categoryOf(it.Category) == catNote {
+				// bei sicheren Notizen ist die Notiz der Inhalt: volle Breite ohne Label, Grundgröße
+				r.Label, r.Wide, r.Size = "", true, w.tpl.Font.Size
+			}
+			rows = append(rows, r)
+		case "fields":
+			keys := make([]string, 0, len(it.RawFields))
+			for k := range it.RawFields {
+				if f.used[k] || strings.EqualFold(k, "username") || strings.EqualFold(k, "password") || strings.EqualFold(k, "notes") {
+					continue
+				}
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				v := it.RawFields[k]
+				class := fieldClass(k, v, it.Concealed[k])
+				if len(v) > 0 {
+					rows = append(rows, row{Label: k, Value: opt.Redact.apply(class, v), Size: size, Secret: class != "" && w.secretVisible(class)})
+				}
+			}
+		default:
+			kv(label, w.fieldText(it, fd.Name), size)
+		}
+	}
+	return rows
+}
+
+// secretVisible meldet, ob Geheimnisse der Feldklasse class im Klartext erscheinen; nur
+// dann werden sie als Geheimnis gesetzt (geschwärzte Werte und Fingerprints nicht).
+func (w *writer) secretVisible(class string) bool {
+	return w.opt.Redact.Fields[class] == 0 && (class != "password" || w.pwfp == nil)
+}
+
+// fieldText ist der Wert eines einfachen Felds als Text, geschwärzt wie eingestellt.
+// Tabellenzellen und Label/Wert-Zeilen verwenden ihn gleichermaßen.
+func (w *writer) fieldText(it model.Item, name string) string {
+	redact := w.opt.Redact
+	switch name {
+	case "title":
+		return displayTitle(it)
+	case "vault":
+		return it.Vault
+	case "category":
+		return it.Category
+	case "username":
+		return it.Username
+	case "password":
+		if w.pwfp != nil && len(it.Password) > 0 {
+			return pwhash.Summary(it.Password) + "\n" + w.pwfp.Fingerprint(it.Password)
+		}
+		return redact.apply("password", it.Password)
+	case "url":
+		return strings.Join(it.URLs, " ")
+	case "totp":
+		return redact.apply("totp", it.TOTP)
+	case "notes":
+		return redact.apply("notes", []byte(it.Notes))
+	case "tags":
+		return strings.Join(it.Tags, ", ")
+	case "flags":
+		var flags []string
+		if it.Favorite {
+			flags = append(flags, "Favorit")
+		}
+		if it.Archived {
+			flags = append(flags, "archiviert")
+		}
+		return strings.Join(flags, ", ")
+	case "created":
+		return formatTime(it.Created)
+	case "updated":
+		return formatTime(it.Updated)
+	case "id":
+		return it.ID
+	}
+	return ""
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// split bricht s so um, wie MultiCell es bei Breite width mit der aktuellen Schrift tun würde.
+func (w *writer) split(s string, width float64) []string {
+	s = strings.TrimRight(strings.ReplaceAll(s, "\r", ""), "\n")
+	if s == "" || w.pdf.Err() {
+		return []string{s}
+	}
+	if w.mono || w.utf8 {
+		return w.pdf.SplitText(s, width)
+	}
+	var lines []string
+	for _, l := range w.pdf.SplitLines([]byte(s), width) {
+		lines = append(lines, string(l))
+	}
+	return lines
+}
+
+func (w *writer) rowHeight(r row) float64 {
+	if r.Markdown {
+		h := mdHeight(w.mdLayout(r.Value, w.valueWidth(r), r.Size))
+		w.textFont()
+		return max(h, w.lineHeight(r))
+	}
+	w.rowFont(r)
+	n := len(w.split(r.Value, w.valueWidth(r)))
+	w.textFont()
+	return float64(n) * w.lineHeight(r)
+}
+
+// lineHeight wächst mit Schriftgrößen über 11 pt, darunter bleibt es bei lineHeight.
+// Karten sind dichter gesetzt und skalieren auch nach unten.
+func (w *writer) lineHeight(r row) float64 {
+	if w.col.w != 0 {
+		return mdLineHeight(r.Size)
+	}
+	return max(lineHeight, mdLineHeight(r.Size))
+}
+
+// valueWidth ist die Breite der Wertspalte.
+func (w *writer) valueWidth(r row) float64 {
+	if r.Wide {
+		return w.colWidth()
+	}
+	return w.colWidth() - w.labelWidth()
+}
+
+// column ist der Bereich, in dem Zeilen gesetzt werden; der Nullwert steht für die
+// ganze Breite zwischen den Rändern, Karten setzen ihre Spalte (siehe writeCards).
+type column struct {
+	x, w float64
+}
+
+func (w *writer) colX() float64 {
+	if w.col.w == 0 {
+		lm, _, _, _ := w.pdf.GetMargins()
+		return lm
+	}
+	return w.col.x
+}
+
+func (w *writer) colWidth() float64 {
+	if w.col.w == 0 {
+		return w.contentWidth()
+	}
+	return w.col.w
+}
+
+// labelWidth ist die Breite der Labelspalte; in schmalen Spalten höchstens 40 % davon.
+func (w *writer) labelWidth() float64 {
+	if w.col.w == 0 {
+		return w.tpl.LabelWidth
+	}
+	return min(w.tpl.LabelWidth, w.col.w*0.4)
+}
+
+// contentWidth ist die Breite zwischen linkem und rechtem Rand.
+func (w *writer) contentWidth() float64 {
+	pw, _ := w.pdf.GetPageSize()
+	lm, _, rm, _ := w.pdf.GetMargins()
+	return pw - lm - rm
+}
+
+// bottom ist die Y-Position des automatischen Seitenumbruchs.
+func (w *writer) bottom() float64 {
+	_, ph := w.pdf.GetPageSize()
+	_, bm := w.pdf.GetAutoPageBreak()
+	return ph - bm
+}
+
+// rowFont stellt Schrift und Größe für den Wert von r ein.
+func (w *writer) rowFont(r row) {
+	if r.Mono || r.Secret {
+		w.pdf.SetFont(w.monoFont, "", r.Size)
+		w.mono = true
+		return
+	}
+	w.pdf.SetFontSize(r.Size)
+}
+
+// textFont stellt die normale Schrift in 11 pt wieder her.
+func (w *writer) textFont() {
+	w.pdf.SetFont(w.family(), "", 11)
+	w.mono = false
+}
+
+// family ist die Textschrift: die Kernschrift der Vorlage, sonst DejaVu Sans.
+func (w *writer) family() string {
+	return w.font
+}
+
+func (w *writer) measure(rows []row) float64 {
+	var h float64
+	for _, r := range rows {
+		h += w.rowHeight(r)
+	}
+	return h
+}
+
+// drawRow zeichnet eine Zeile. Passt sie nicht mehr auf die Seite, wohl aber auf eine leere,
+// wird vorher umgebrochen; noch längere Werte bricht gofpdf zeilenweise automatisch um.
+func (w *writer) drawRow(r row) {
+	pdf := w.pdf
+	if h := w.rowHeight(r); h > w.remaining() && h <= w.pageCapacity() {
+		pdf.AddPage()
+	}
+	pdf.SetX(w.colX())
+	if !r.Wide {
+		pdf.SetFontSize(r.Size)
+		pdf.CellFormat(w.labelWidth(), w.lineHeight(r), r.Label, "", 0, "", false, 0, "")
+	}
+	if r.Markdown {
+		w.drawMarkdown(w.mdLayout(r.Value, w.valueWidth(r), r.Size), pdf.GetX())
+		pdf.SetX(w.colX())
+		return
+	}
+	w.rowFont(r)
+	if r.Secret && w.opt.PasswordColors {
+		x, lh := pdf.GetX(), w.lineHeight(r)
+		for _, l := range w.split(r.Value, w.valueWidth(r)) {
+			if pdf.GetY()+lh > w.bottom() {
+				pdf.AddPage()
+				w.rowFont(r)
+			}
+			w.drawColored(l, x, pdf.GetY(), lh)
+			pdf.SetY(pdf.GetY() + lh)
+		}
+		pdf.SetX(w.colX())
+		w.textFont()
+		return
+	}
+	pdf.MultiCell(w.valueWidth(r), w.lineHeight(r), r.Value, "", "", false)
+	w.textFont()
+}
+
+// drawRows zeichnet die Zeilen eines Items und rahmt zusammenhängende Box-Zeilen ein.
+func (w *writer) drawRows(rows []row) {
+	pdf := w.pdf
+	var boxY float64
+	boxPage := 0
+	for i, r := range rows {
+		if r.Box && (i == 0 || !rows[i-1].Box) {
+			boxY, boxPage = pdf.GetY(), pdf.PageNo()
+		}
+		w.drawRow(r)
+		if r.Box && (i == len(rows)-1 || !rows[i+1].Box) && pdf.PageNo() == boxPage {
+			// Rahmen im Kartenformat, nur wenn der Block nicht umgebrochen wurde
+			pdf.RoundedRect(w.colX()-1, boxY-1, min(cardWidth, w.colWidth()+2), pdf.GetY()-boxY+2, 3, "1234", "D")
+		}
+	}
+}
+
+// remaining ist der Platz bis zum automatischen Seitenumbruch auf der aktuellen Seite.
+func (w *writer) remaining() float64 {
+	return w.bottom() - w.pdf.GetY()
+}
+
+// pageCapacity ist der Platz einer Folgeseite unter Kopf und Fortsetzungsmarke.
+func (w *writer) pageCapacity() float64 {
+	return w.bottom() - w.top - 6
+}
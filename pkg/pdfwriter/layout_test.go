@@ -0,0 +1,76 @@
+package pdfwriter
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/secret"
+)
+
+// render setzt items mit der Vorlage aus src (über compact gelegt) unverschlüsselt und
+// unkomprimiert, damit der Text im Ergebnis zu finden ist.
+func render(t *testing.T, src string, items []model.Item) []byte {
+	t.Helper()
+	base, err := BuiltinTemplate("compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl, err := parseTemplate([]byte(src), *base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := newWriter(Options{Template: tpl, UserPassword: secret.New("test")}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.pdf.SetCompression(false)
+	w.pdf.AddPage()
+	w.writeItems(items)
+	var buf bytes.Buffer
+	if err := w.pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// shows meldet, ob s im Inhalt steht, als Kernschrift-Text oder in den UTF-16-Codes der DejaVu-Schriften.
+func shows(pdf []byte, s string) bool {
+	var u bytes.Buffer
+	for _, c := range utf16.Encode([]rune(s)) {
+		u.Write([]byte{byte(c >> 8), byte(c)})
+	}
+	return bytes.Contains(pdf, []byte(s)) || bytes.Contains(pdf, u.Bytes())
+}
+
+func TestCategoryFieldsWithoutCategory(t *testing.T) {
+	items := []model.Item{
+		{Title: "Visa", Category: "CREDIT_CARD", RawFields: map[string]secret.Bytes{
+			"number":              secret.New("4111111111111111"),
+			"verification number": secret.New("123"),
+		}},
+		{Title: "Konto", Category: "BANK_ACCOUNT", RawFields: map[string]secret.Bytes{
+			"iban": secret.New("DE89370400440532013000"),
+		}},
+	}
+	tests := []struct {
+		name string
+		tpl  string
+		want []string
+	}{
+		// ohne category stehen die Kartenfelder unverändert unter fields
+		{"fields", "fields: [username, fields]", []string{"4111111111111111", "DE89370400440532013000"}},
+		{"fields cards", "style: cards\nfields: [fields]", []string{"4111111111111111", "DE89370400440532013000"}},
+		{"category", "fields: [category, fields]", []string{"4111 1111 1111 1111", "DE89 3704 0044 0532 0130 00"}},
+		{"category after fields", "fields: [fields, category]", []string{"4111 1111 1111 1111", "DE89 3704 0044 0532 0130 00"}},
+	}
+	for _, tt := range tests {
+		out := render(t, tt.tpl, items)
+		for _, s := range tt.want {
+			if !shows(out, s) {
+				t.Errorf("%s: %q fehlt im PDF", tt.name, s)
+			}
+		}
+	}
+}
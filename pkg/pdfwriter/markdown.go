@@ -0,0 +1,334 @@
+package pdfwriter
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/example/onepw-pdf-export/pkg/markdown"
+)
+
+// Notizen werden als Markdown gesetzt. mdLayout bricht den Text einmal in fertig
+// positionierte Zeilen um; rowHeight misst diese Zeilen, drawMarkdown zeichnet sie.
+// So stimmen Höhe und Ausgabe überein, und Seitenumbrüche fallen zwischen zwei Zeilen.
+
+// mdLine ist eine gesetzte Zeile (bei Tabellen eine ganze Tabellenzeile).
+type mdLine struct {
+	gap   float64 // Abstand davor
+	h     float64
+	frags []mdFrag
+	boxes []mdBox
+}
+
+// mdFrag ist ein Textstück mit einheitlicher Schrift; x und dy relativ zum Zeilenanfang.
+type mdFrag struct {
+	x, dy, w, h float64
+	text        string
+	style       string // "", "B", "I", "BI"
+	mono        bool
+	size        float64
+	link        string
+}
+
+// mdBox ist eine Fläche bzw. ein Rahmen hinter der Zeile (Codeblock, Zitatbalken, Tabellenzelle).
+type mdBox struct {
+	x, w  float64
+	style string // "F", "D" oder "FD"
+	gray  int    // Füllfarbe
+	rule  bool   // waagrechte Linie in der Mitte statt Fläche
+}
+
+const (
+	mdIndent  = 5.0 // Einrückung je Listenebene und für Zitate
+	mdCellPad = 1.5 // Innenabstand von Tabellenzellen und Codeblöcken
+)
+
+// mdLineHeight skaliert die Zeilenhöhe mit der Schriftgröße (lineHeight gilt für 11 pt).
+func mdLineHeight(size float64) float64 {
+	return lineHeight * size / 11
+}
+
+// mdLayout setzt src in der Breite width mit der Grundschriftgröße size.
+func (w *writer) mdLayout(src string, width, size float64) []mdLine {
+	var out []mdLine
+	blocks := markdown.Parse(src)
+	for i, b := range blocks {
+		gap := size * 0.25
+		if i == 0 || b.Kind == markdown.ListItem && blocks[i-1].Kind == markdown.ListItem {
+			gap = 0
+		}
+		start := len(out)
+		switch b.Kind {
+		case markdown.Heading:
+			hs := size + max(0, float64(4-b.Level))*1.5
+			if b.Level <= 3 && i > 0 {
+				gap = size * 0.4
+			}
+			out = append(out, w.mdWrap(b.Lines, 0, width, hs, "B")...)
+
+		case markdown.ListItem:
+			x := float64(b.Level) * mdIndent
+			mw := max(mdIndent, w.mdWidth(b.Marker, "", false, size)+1.5)
+			lines := w.mdWrap(b.Lines, x+mw, width, size, "")
+			if len(lines) > 0 {
+				lines[0].frags = append([]mdFrag{{x: x, w: mw, h: lines[0].h, text: b.Marker, size: size}}, lines[0].frags...)
+			}
+			out = append(out, lines...)
+
+		case markdown.Quote:
+			lines := w.mdWrap(b.Lines, mdIndent, width, size, "I")
+			for j := range lines {
+				lines[j].boxes = append(lines[j].boxes, mdBox{x: 1, w: 0.8, style: "F", gray: 170})
+			}
+			out = append(out, lines...)
+
+		case markdown.Code:
+			cs := size - 1.5
+			for _, t := range b.Text {
+				// eine Codezeile bleibt eine Zeile, zu lange werden ohne Rücksicht auf Wörter umbrochen
+				spans := [][]markdown.Span{{{Text: t, Code: true}}}
+				lines := w.mdWrap(spans, mdCellPad, width-mdCellPad, cs, "")
+				out = append(out, lines...)
+			}
+			for j := start; j < len(out); j++ {
+				out[j].boxes = append(out[j].boxes, mdBox{w: width, style: "F", gray: 238})
+			}
+
+		case markdown.Rule:
+			out = append(out, mdLine{h: size * 0.35, boxes: []mdBox{{w: width, rule: true}}})
+
+		case markdown.Table:
+			out = append(out, w.mdTable(b, width, size-1)...)
+
+		default:
+			out = append(out, w.mdWrap(b.Lines, 0, width, size, "")...)
+		}
+		if len(out) > start {
+			out[start].gap = gap
+		}
+	}
+	return out
+}
+
+// mdHeight ist die Höhe des gesetzten Markdown-Texts.
+func mdHeight(lines []mdLine) float64 {
+	var h float64
+	for _, l := range lines {
+		h += l.gap + l.h
+	}
+	return h
+}
+
+// mdWrap bricht Zeilen aus Spans ab x in der Breite width um; jede Eingabezeile beginnt neu.
+// base ist der Schriftstil des ganzen Blocks (z. B. "B" für Überschriften).
+func (w *writer) mdWrap(src [][]markdown.Span, x, width, size float64, base string) []mdLine {
+	lh := mdLineHeight(size)
+	var out []mdLine
+	for _, spans := range src {
+		line := mdLine{h: lh}
+		cx := x
+		add := func(f mdFrag) {
+			f.x, f.h, f.size = cx, lh, size
+			cx += f.w
+			if n := len(line.frags); n > 0 {
+				if p := &line.frags[n-1]; p.style == f.style && p.mono == f.mono && p.link == f.link {
+					p.text += f.text
+					p.w += f.w
+					return
+				}
+			}
+			line.frags = append(line.frags, f)
+		}
+		for _, sp := range spans {
+			style := mdStyle(base, sp)
+			for _, piece := range pieces(sp.Text) {
+				word := strings.TrimRight(piece, " ")
+				ww := w.mdWidth(word, style, sp.Code, size)
+				if cx > x && cx+ww > x+width {
+					out = append(out, line)
+					line, cx = mdLine{h: lh}, x
+				}
+				// Wörter, die allein nicht in eine Zeile passen (URLs, Schlüssel), zeichenweise
+				for ww > width {
+					n := w.mdFit(word, style, sp.Code, size, width)
+					add(mdFrag{w: w.mdWidth(word[:n], style, sp.Code, size), text: word[:n], style: style, mono: sp.Code, link: sp.Link})
+					out = append(out, line)
+					line, cx = mdLine{h: lh}, x
+					word, piece = word[n:], piece[n:]
+					ww = w.mdWidth(word, style, sp.Code, size)
+				}
+				add(mdFrag{w: w.mdWidth(piece, style, sp.Code, size), text: piece, style: style, mono: sp.Code, link: sp.Link})
+			}
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// pieces zerlegt s in Wörter samt folgender Leerzeichen; führende Leerzeichen bleiben ein eigenes Stück.
+func pieces(s string) []string {
+	var out []string
+	start := 0
+	for i := 1; i <= len(s); i++ {
+		if i == len(s) || s[i] != ' ' && s[i-1] == ' ' {
+			out = append(out, s[start:i])
+			start = i
+		}
+	}
+	return out
+}
+
+func mdStyle(base string, sp markdown.Span) string {
+	style := base
+	if sp.Bold && !strings.Contains(style, "B") {
+		style += "B"
+	}
+	if sp.Italic && !strings.Contains(style, "I") {
+		style += "I"
+	}
+	return style
+}
+
+// mdFont stellt Schrift, Stil und Größe eines Fragments ein; Code in der Festbreitenschrift.
+func (w *writer) mdFont(style string, mono bool, size float64) {
+	family := w.family()
+	if mono {
+		family = w.monoFont
+	}
+	w.pdf.SetFont(family, style, size)
+	w.mono = mono
+}
+
+func (w *writer) mdWidth(s, style string, mono bool, size float64) float64 {
+	if s == "" {
+		return 0
+	}
+	w.mdFont(style, mono, size)
+	return w.pdf.GetStringWidth(s)
+}
+
+// mdFit liefert, wie viele Bytes von s (ganze Zeichen, mindestens eines) in width passen.
+func (w *writer) mdFit(s, style string, mono bool, size, width float64) int {
+	n := 0
+	for i := range s {
+		if i > 0 && w.mdWidth(s[:i], style, mono, size) > width {
+			break
+		}
+		n = i
+	}
+	if n == 0 {
+		_, n = utf8.DecodeRuneInString(s)
+	}
+	return n
+}
+
+// mdTable setzt eine Tabelle: Spalten so breit wie ihr Inhalt, bei Platzmangel anteilig
+// verkleinert; die Kopfzeile fett und grau hinterlegt.
+func (w *writer) mdTable(b markdown.Block, width, size float64) []mdLine {
+	n := 0
+	for _, r := range b.Rows {
+		n = max(n, len(r))
+	}
+	cols := make([]float64, n)
+	for ri, r := range b.Rows {
+		for ci, c := range r {
+			var cw float64
+			for _, sp := range c {
+				cw += w.mdWidth(sp.Text, mdStyle(headStyle(ri), sp), sp.Code, size)
+			}
+			cols[ci] = max(cols[ci], cw+2*mdCellPad+1)
+		}
+	}
+	var total float64
+	for _, c := range cols {
+		total += c
+	}
+	if total > width {
+		for i := range cols {
+			cols[i] *= width / total
+		}
+	}
+
+	var out []mdLine
+	for ri, r := range b.Rows {
+		line := mdLine{}
+		x := 0.0
+		for ci, cw := range cols {
+			var cell []markdown.Span
+			if ci < len(r) {
+				cell = r[ci]
+			}
+			inner := cw - 2*mdCellPad
+			var dy float64
+			for _, l := range w.mdWrap([][]markdown.Span{cell}, x+mdCellPad, inner, size, headStyle(ri)) {
+				shift := 0.0
+				if ci < len(b.Align) && b.Align[ci] != markdown.AlignLeft && len(l.frags) > 0 {
+					last := l.frags[len(l.frags)-1]
+					used := last.x + w.mdWidth(strings.TrimRight(last.text, " "), last.style, last.mono, size) - (x + mdCellPad)
+					if shift = inner - used; b.Align[ci] == markdown.AlignCenter {
+						shift /= 2
+					}
+				}
+				for _, f := range l.frags {
+					f.x += shift
+					f.dy = dy
+					line.frags = append(line.frags, f)
+				}
+				dy += l.h
+			}
+			line.h = max(line.h, dy)
+			box := mdBox{x: x, w: cw, style: "D"}
+			if ri == 0 {
+				box.style, box.gray = "FD", 230
+			}
+			line.boxes = append(line.boxes, box)
+			x += cw
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+func headStyle(row int) string {
+	if row == 0 {
+		return "B"
+	}
+	return ""
+}
+
+// drawMarkdown zeichnet gesetzte Zeilen ab x0 und der aktuellen Y-Position. Passt eine Zeile
+// nicht mehr auf die Seite, beginnt sie auf der nächsten; Links werden klickbar.
+func (w *writer) drawMarkdown(lines []mdLine, x0 float64) {
+	pdf := w.pdf
+	for _, l := range lines {
+		y := pdf.GetY() + l.gap
+		if y+l.h > w.bottom() {
+			pdf.AddPage()
+			y = pdf.GetY()
+		}
+		for _, b := range l.boxes {
+			if b.rule {
+				pdf.SetDrawColor(160, 160, 160)
+				pdf.Line(x0+b.x, y+l.h/2, x0+b.x+b.w, y+l.h/2)
+				continue
+			}
+			pdf.SetFillColor(b.gray, b.gray, b.gray)
+			pdf.SetDrawColor(150, 150, 150)
+			pdf.Rect(x0+b.x, y, b.w, l.h, b.style)
+		}
+		for _, f := range l.frags {
+			style := f.style
+			if f.link != "" {
+				style += "U"
+				pdf.SetTextColor(0, 0, 160)
+			}
+			w.mdFont(style, f.mono, f.size)
+			pdf.SetXY(x0+f.x, y+f.dy)
+			pdf.CellFormat(f.w, f.h, f.text, "", 0, "", false, 0, f.link)
+			pdf.SetTextColor(0, 0, 0)
+		}
+		pdf.SetY(y + l.h)
+	}
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetFillColor(255, 255, 255)
+	w.textFont()
+}
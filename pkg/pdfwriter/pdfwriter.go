@@ -1,125 +1,310 @@
 package pdfwriter
 
 import (
+	"bytes"
 	"crypto/rand"
+	"crypto/x509"
 	"encoding/hex"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/jung-kurt/gofpdf"
+	"github.com/example/onepw-pdf-export/pkg/audit"
 	"github.com/example/onepw-pdf-export/pkg/fonts"
 	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/pdfcrypt"
+	"github.com/example/onepw-pdf-export/pkg/pwhash"
+	"github.com/example/onepw-pdf-export/pkg/secret"
 )
 
+
 type Options struct {
-	Template     string // compact | detailed
-	MaskPassword bool
-	Source       string // csv | live/op | 1pux
-	UserPassword string // PDF user password (required)
+	Template       *Template // Layout-Vorlage (nil: compact)
+	Redact         Redaction // welche Felder geschwärzt werden (Nullwert: keine)
+	// PasswordFingerprints druckt statt der Passwörter Länge, Zeichenklassen und einen
+	// gesalzenen Fingerprint; verify prüft Kandidaten gegen das eingebettete Manifest.
+	PasswordFingerprints bool
+	PasswordColors   bool // Geheimnisse nach Zeichenklassen färben (Ziffern, Sonderzeichen, Groß-/Kleinbuchstaben)
+	PasswordSpelling bool // unter jedem Passwort eine Zeile im NATO-Alphabet
+	Source         string              // csv | live/op | 1pux
+	UserPassword   secret.Bytes        // PDF user password (required unless Recipients)
+	OwnerPassword  secret.Bytes        // Vollzugriff; leer = zufällig und nicht wiederherstellbar
+	Recipients     []*x509.Certificate // statt Passwort: Empfänger, die das PDF mit ihrem Schlüssel öffnen
+	Permissions    pdfcrypt.Permission
+	SortBy         string // einer der model.SortKeys (leer: Reihenfolge der Quelle)
+	GroupBy        string // einer der GroupKeys (leer: keine Gruppen)
+	Label          string // optionaler Vermerk im Seitenkopf, z. B. "VERTRAULICH"
+	Watermark      string // diagonales, halbtransparentes Wasserzeichen auf jeder Seite (optional)
+	Classification string // Einstufung als Balken oben und unten auf jeder Seite (optional)
+	Audit          *audit.Options // Passwort-Audit als Anhang (nil: keiner)
 }
 
-func randomOwnerPassword() string {
+// RandomOwnerPassword erzeugt ein zufälliges Eigentümerpasswort (128 Bit, hex).
+func RandomOwnerPassword() secret.Bytes {
 	var b [16]byte
 	_, _ = rand.Read(b[:])
-	return hex.EncodeToString(b[:])
+	pw := make([]byte, hex.EncodedLen(len(b)))
+	hex.Encode(pw, b[:])
+	secret.Zero(b[:])
+	defer secret.Zero(pw)
+	return secret.FromBytes(pw)
 }
 
-func mask(s string, on bool) string {
-	if !on { return s }
-	if s == "" { return "" }
-	return strings.Repeat("•", 8)
+
+// writer bündelt das gofpdf-Dokument mit dem Zustand, der über Seitenumbrüche hinweg gebraucht wird.
+type writer struct {
+	pdf      *gofpdf.Fpdf
+	opt      Options
+	tpl      *Template
+	font     string  // Textschrift (siehe family)
+	utf8     bool    // UTF-8-Schrift aktiv (sonst Kernschrift der Vorlage)
+	mono     bool    // gerade die Festbreitenschrift gesetzt (siehe rowFont)
+	monoFont string  // Festbreitenschrift: DejaVu Sans Mono
+	top      float64 // Y-Position unter dem Seitenkopf, gesetzt im Header
+	meta     string  // Exportzeitpunkt, Quelle und Anzahl für den Seitenkopf
+	fp       string  // Dokument-Fingerprint für die Fußzeile
+	cont     string  // Titel des Items, das gerade über eine Seitengrenze läuft
+	col      column  // Spalte, in der Zeilen gesetzt werden (Nullwert: ganze Breite)
+	thead    func()  // Kopfzeile einer laufenden Tabelle, wiederholt auf jeder neuen Seite
+	index    []indexEntry
+	pwfp     *pwhash.Hasher // gesetzt bei Options.PasswordFingerprints
 }
 
 func WritePDF(path string, items []model.Item, opt Options) error {
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.SetTitle("1Password Export", false)
-	pdf.SetAuthor("onepw-pdf-export", false)
+	// Sortieren auf einer Kopie, damit der Aufrufer seine Reihenfolge behält
+	sorted := make([]model.Item, len(items))
+	copy(sorted, items)
+	if err := model.SortItems(sorted, opt.SortBy); err != nil {
+		return fmt.Errorf("pdfwriter: %w", err)
+	}
+	groups, err := groupItems(sorted, opt.GroupBy)
+	if err != nil {
+		return err
+	}
+	// Audit vorab, damit z. B. eine fehlende HIBP-Datei vor dem Rendern auffällt
+	var rep *audit.Report
+	if opt.Audit != nil {
+		r, err := audit.Run(sorted, *opt.Audit)
+		if err != nil {
+			return err
+		}
+		rep = &r
+	}
 
-	// UTF-8 capable font
-	if err := fonts.EnsureUTF8Font(pdf); err == nil {
-		pdf.SetFont(fonts.FontName, "", 12)
-	} else {
-		// Fallback (no full UTF‑8)
-		pdf.SetFont("Helvetica", "", 12)
+	now := time.Now()
+	w, err := newWriter(opt, fmt.Sprintf("Exportiert: %s · Quelle: %s · Items: %d", now.Format("2006-01-02 15:04 MST"), opt.Source, len(items)), fingerprint(now, sorted))
+	if err != nil {
+		return err
+	}
+	pdf := w.pdf
+	if opt.PasswordFingerprints {
+		if err := w.attachFingerprints(sorted); err != nil {
+			return err
+		}
+	}
+	pdf.AddPage()
+	if w.pwfp != nil {
+		w.writeFingerprintNote()
+	} else if w.opt.Redact.hasher != nil {
+		w.writeRedactNote()
+	}
+	if opt.PasswordColors {
+		w.writeColorLegend()
 	}
 
-	// Protection
-	if opt.UserPassword == "" {
-		return fmt.Errorf("pdfwriter: UserPassword ist leer")
+	// Indexeintrag je gedrucktem Item, für Verweise aus späteren Tag-Gruppen
+	placed := map[int]indexEntry{}
+	for i, g := range groups {
+		if g.Name != "" {
+			// jede Gruppe beginnt auf einer neuen Seite (die erste direkt unter dem Kopf)
+			if i > 0 {
+				pdf.AddPage()
+			}
+			w.writeGroupHeading(g.Name, len(g.Items)+len(g.refs))
+		}
+		start := len(w.index)
+		w.writeItems(g.Items)
+		if len(w.index)-start == len(g.ids) {
+			for j, id := range g.ids {
+				placed[id] = w.index[start+j]
+			}
+		}
+		refs := make([]indexEntry, 0, len(g.refs))
+		for _, id := range g.refs {
+			if e, ok := placed[id]; ok {
+				refs = append(refs, e)
+			}
+		}
+		w.writeRefs(refs)
+	}
+	w.writeIndex()
+	if rep != nil {
+		w.writeAudit(*rep)
 	}
-	pdf.SetProtection(gofpdf.CnProtectPrint, opt.UserPassword, randomOwnerPassword())
+	return w.save(path)
+}
 
-	pdf.AddPage()
+// newWriter legt das Dokument mit Schrift, Kopf- und Fußzeile an; meta und fp erscheinen
+// im Seitenkopf bzw. in der Fußzeile.
+func newWriter(opt Options, meta, fp string) (*writer, error) {
+	tpl := opt.Template
+	if tpl == nil {
+		tpl = defaultTemplate()
+	}
+	orientation := "P"
+	if strings.EqualFold(tpl.Page.Orientation, "landscape") {
+		orientation = "L"
+	}
+	pdf := gofpdf.New(orientation, "mm", tpl.Page.Size, "")
+	pdf.SetTitle("1Password Export", false)
+	pdf.SetAuthor("onepw-pdf-export", false)
+	m := tpl.Page.Margins
+	pdf.SetMargins(m.Left, m.Top, m.Right)
+	pdf.SetAutoPageBreak(true, m.Bottom)
+	w := &writer{
+		pdf:  pdf,
+		opt:  opt,
+		tpl:  tpl,
+		meta: meta,
+		fp:   fp,
+	}
+	// ein Salz je Export, das attachFingerprints mitbenutzt
+	if opt.Redact.hashes() {
+		h, err := pwhash.NewHasher()
+		if err != nil {
+			return nil, err
+		}
+		w.opt.Redact.hasher = h
+	}
 
-	// Header
-	pdf.Cell(0, 6, fmt.Sprintf("Exportzeitpunkt: %s", time.Now().Format(time.RFC3339)))
-	pdf.Ln(6)
-	pdf.Cell(0, 6, fmt.Sprintf("Quelle: %s | Items: %d", opt.Source, len(items)))
-	pdf.Ln(10)
+	// eingebettete UTF-8-Schriften werden immer registriert: Geheimnisse stehen auch bei einer
+	// Kernschrift der Vorlage in DejaVu Sans Mono, nur der übrige Text wechselt die Schrift
+	if err := fonts.Register(pdf); err != nil {
+		return nil, fmt.Errorf("pdfwriter: Schriften: %w", err)
+	}
+	w.font, w.utf8, w.monoFont = fonts.FontName, true, fonts.MonoName
+	if tpl.Font.Family != "" {
+		w.font, w.utf8 = tpl.Font.Family, false
+	}
+	pdf.SetFont(w.font, "", 12)
 
-	for _, it := range items {
-		writeItem(pdf, it, opt)
+	// Protection (AES-256 wird nach dem Rendern angewendet, siehe save)
+	if len(opt.UserPassword) == 0 && len(opt.Recipients) == 0 {
+		return nil, fmt.Errorf("pdfwriter: UserPassword ist leer")
 	}
 
-	return pdf.OutputFileAndClose(path)
+	pdf.AliasNbPages(pageCountAlias)
+	pdf.SetHeaderFunc(w.header)
+	pdf.SetFooterFunc(w.footer)
+	return w, nil
+}
+
+// save verschlüsselt das fertige Dokument und schreibt es nach path.
+func (w *writer) save(path string) error {
+	opt := w.opt
+	// gofpdf kann nur RC4; das fertige Dokument wird daher im Speicher gerendert
+	// und anschließend mit AES-256 (PDF 2.0, Revision 6) verschlüsselt.
+	var plain bytes.Buffer
+	if err := w.pdf.Output(&plain); err != nil {
+		return err
+	}
+	// Das unverschlüsselte Dokument enthält alle Passwörter im Klartext
+	defer secret.Zero(plain.Bytes())
+	owner := opt.OwnerPassword
+	if len(owner) == 0 && len(opt.UserPassword) > 0 {
+		owner = RandomOwnerPassword()
+		defer owner.Wipe()
+	}
+	enc, err := pdfcrypt.Encrypt(plain.Bytes(), pdfcrypt.Options{
+		UserPassword:  opt.UserPassword,
+		OwnerPassword: owner,
+		Recipients:    opt.Recipients,
+		Permissions:   opt.Permissions,
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, enc, 0o600)
 }
 
-func writeItem(pdf *gofpdf.Fpdf, it model.Item, opt Options) {
-	w, h := 190.0, 6.0
+func displayTitle(it model.Item) string {
+	if it.Title == "" { return "(ohne Titel)" }
+	return it.Title
+}
 
-	// Titelzeile
-	pdf.SetFontStyle("B")
-	title := it.Title
-	if title == "" { title = "(ohne Titel)" }
+// itemMeta ist die Zeile unter dem Titel: Tresor und Kategorie.
+func itemMeta(it model.Item) string {
 	meta := it.Vault
 	if it.Category != "" {
 		if meta != "" { meta += " · " }
 		meta += it.Category
 	}
-	pdf.CellFormat(0, 7, title, "", 1, "", false, 0, "")
+	return meta
+}
+
+// writeItems setzt die Items einer Gruppe in der Anordnung der Vorlage.
+func (w *writer) writeItems(items []model.Item) {
+	switch w.tpl.style() {
+	case "table":
+		w.writeTable(items)
+	case "cards":
+		w.writeCards(items)
+	default:
+		for _, it := range items {
+			w.writeItem(it)
+		}
+	}
+}
+
+func (w *writer) writeGroupHeading(name string, n int) {
+	// Überschrift nicht allein am Seitenende stehen lassen
+	if w.remaining() < 9+3+20 {
+		w.pdf.AddPage()
+	}
+	w.pdf.SetFontStyle("B")
+	w.pdf.SetFontSize(14)
+	w.pdf.CellFormat(0, 9, fmt.Sprintf("%s (%d)", name, n), "B", 1, "", false, 0, "")
+	w.pdf.Ln(3)
+	w.pdf.SetFontSize(11)
+}
+
+// writeItem misst das Item vorab: passt es nicht mehr auf die Seite, aber auf eine leere,
+// beginnt es auf der nächsten. Längere Items werden zeilenweise umbrochen und auf jeder
+// Folgeseite mit "(Fortsetzung)" markiert.
+func (w *writer) writeItem(it model.Item) {
+	pdf := w.pdf
+	title, meta := displayTitle(it), itemMeta(it)
+	rows := w.itemRows(it)
+	height := titleHeight + w.measure(rows) + itemGap
+	if meta != "" {
+		height += metaHeight
+	}
+
+	if height > w.remaining() && (height <= w.pageCapacity() || w.remaining() < minSplitSpace) {
+		pdf.AddPage()
+	}
+
+	link := pdf.AddLink()
+	pdf.SetLink(link, pdf.GetY(), pdf.PageNo())
+	w.index = append(w.index, indexEntry{Title: title, Page: pdf.PageNo(), Link: link})
+
+	// Titelzeile
+	pdf.SetFontStyle("B")
+	pdf.CellFormat(0, titleHeight, title, "", 1, "", false, 0, "")
 	if meta != "" {
 		pdf.SetFontStyle("")
 		pdf.SetFontSize(10)
-		pdf.CellFormat(0, 5, meta, "", 1, "", false, 0, "")
+		pdf.CellFormat(0, metaHeight, meta, "", 1, "", false, 0, "")
 		pdf.SetFontSize(11)
 	}
 
 	// Inhalt
 	pdf.SetFontStyle("")
+	w.cont = title
+	w.drawRows(rows)
+	w.cont = ""
 
-	kv := func(k, v string) {
-		if v == "" { return }
-		pdf.CellFormat(30, h, k, "", 0, "", false, 0, "")
-		pdf.MultiCell(w, h, v, "", "", false)
-	}
-
-	if opt.Template == "compact" {
-		kv("Username", it.Username)
-		kv("Passwort", mask(it.Password, opt.MaskPassword))
-		if len(it.URLs) > 0 { kv("URL", strings.Join(it.URLs, " ")) }
-		kv("TOTP", it.TOTP)
-		if it.Notes != "" {
-			pdf.SetFontSize(10)
-			kv("Notizen", it.Notes)
-			pdf.SetFontSize(11)
-		}
-	} else {
-		kv("Username", it.Username)
-		kv("Passwort", mask(it.Password, opt.MaskPassword))
-		if len(it.URLs) > 0 { kv("URL", strings.Join(it.URLs, " ")) }
-		kv("TOTP", it.TOTP)
-		if it.Notes != "" { kv("Notizen", it.Notes) }
-		for k, v := range it.RawFields {
-			if strings.EqualFold(k, "username") || strings.EqualFold(k, "password") || strings.EqualFold(k, "notes") {
-				continue
-			}
-			kv(k, v)
-		}
-	}
-
-	pdf.Ln(2)
-	if pdf.GetY() > 270 {
-		pdf.AddPage()
-	}
+	pdf.Ln(itemGap)
 }
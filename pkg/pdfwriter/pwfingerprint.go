@@ -0,0 +1,75 @@
+package pdfwriter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/pwhash"
+)
+
+// attachFingerprints berechnet die Passwort-Fingerprints aller Items und bettet sie als
+// Manifest in das PDF ein. Das Manifest wird mit dem Dokument verschlüsselt.
+func (w *writer) attachFingerprints(items []model.Item) error {
+	h := w.opt.Redact.hasher
+	if h == nil {
+		var err error
+		if h, err = pwhash.NewHasher(); err != nil {
+			return err
+		}
+	}
+	w.pwfp = h
+	m := pwhash.Manifest{Version: pwhash.Version, KDF: "argon2id", Params: h.Params}
+	for _, it := range items {
+		if len(it.Password) == 0 {
+			continue
+		}
+		m.Items = append(m.Items, pwhash.ManifestItem{
+			Title:       displayTitle(it),
+			Vault:       it.Vault,
+			Fingerprint: h.Fingerprint(it.Password),
+		})
+	}
+	raw, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	w.pdf.SetAttachments([]gofpdf.Attachment{{
+		Content:     raw,
+		Filename:    pwhash.ManifestName,
+		Description: "Passwort-Fingerprints für onepw-pdf-export verify",
+	}})
+	return nil
+}
+
+// writeFingerprintNote erklärt auf der ersten Seite, wie die Fingerprints geprüft werden.
+func (w *writer) writeFingerprintNote() {
+	pdf := w.pdf
+	pdf.SetFontSize(9)
+	pdf.SetTextColor(80, 80, 80)
+	pdf.MultiCell(w.contentWidth(), 4.5, fmt.Sprintf(
+		"Passwörter sind nur als Fingerprint abgedruckt (Argon2id, %d KiB, %d Durchläufe, Salz %s). "+
+			"Prüfen mit: onepw-pdf-export verify --export <PDF> oder ohne PDF mit --salt und --fingerprint. "+
+			"Gleiche Fingerprints bedeuten gleiche Passwörter.",
+		w.pwfp.Memory, w.pwfp.Time, w.pwfp.SaltString()), "", "", false)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFontSize(11)
+	pdf.Ln(3)
+}
+
+// writeRedactNote nennt Salz und Kosten der mit --redact …:hash gedruckten Fingerprints.
+func (w *writer) writeRedactNote() {
+	h := w.opt.Redact.hasher
+	pdf := w.pdf
+	pdf.SetFontSize(9)
+	pdf.SetTextColor(80, 80, 80)
+	pdf.MultiCell(w.contentWidth(), 4.5, fmt.Sprintf(
+		"Mit „Argon2id:“ markierte Werte sind nur als Fingerprint abgedruckt (%d KiB, %d Durchläufe, Salz %s). "+
+			"Prüfen mit: onepw-pdf-export verify --salt <Salz> --fingerprint <Fingerprint>.",
+		h.Memory, h.Time, h.SaltString()), "", "", false)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFontSize(11)
+	pdf.Ln(3)
+}
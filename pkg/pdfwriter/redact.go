@@ -0,0 +1,177 @@
+package pdfwriter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/example/onepw-pdf-export/pkg/pwhash"
+)
+
+// RedactMode legt fest, wie ein geschwärztes Feld im PDF erscheint.
+type RedactMode int
+
+const (
+	RedactFull    RedactMode = iota + 1 // •••••••• statt des Werts
+	RedactPartial                       // nur die ersten und letzten Zeichen sichtbar
+	RedactHash                          // gesalzener Argon2id-Fingerprint zum Abgleich mit dem Tresor
+)
+
+// RedactModes listet die Namen der Modi für --redact.
+var RedactModes = []string{"full", "partial", "hash"}
+
+// RedactFields listet die Feldklassen, die geschwärzt werden können.
+var RedactFields = []string{"password", "totp", "notes", "concealed", "cc"}
+
+// noHash sind Feldklassen mit so wenigen möglichen Werten (CVV, PIN, Kartennummer mit
+// bekannter BIN), dass auch ein gesalzener, langsamer Hash sie verriete.
+var noHash = []string{"cc", "concealed"}
+
+// Redaction ordnet Feldklassen einen Schwärzungsmodus zu. Der Nullwert schwärzt nichts.
+type Redaction struct {
+	Fields map[string]RedactMode
+	Keep   int // sichtbare Zeichen am Anfang und Ende bei RedactPartial
+
+	hasher *pwhash.Hasher // Salz des Exports für RedactHash, gesetzt in newWriter
+}
+
+// ParseRedaction liest eine Liste wie "password,totp:hash,cc:partial". Einträge ohne
+// eigenen Modus erhalten defaultMode. Bei "all" mit hash werden cc und concealed voll
+// geschwärzt; einzeln angefordert ist hash für sie ein Fehler.
+func ParseRedaction(spec, defaultMode string, keep int) (Redaction, error) {
+	r := Redaction{Fields: map[string]RedactMode{}, Keep: keep}
+	def, err := parseRedactMode(defaultMode)
+	if err != nil {
+		return r, err
+	}
+	if keep < 1 {
+		return r, fmt.Errorf("--redact-keep muss mindestens 1 sein, nicht %d", keep)
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		field, modeName, hasMode := strings.Cut(part, ":")
+		mode := def
+		if hasMode {
+			if mode, err = parseRedactMode(modeName); err != nil {
+				return r, err
+			}
+		}
+		if field == "all" {
+			for _, f := range RedactFields {
+				r.Fields[f] = mode
+				if mode == RedactHash && contains(noHash, f) {
+					r.Fields[f] = RedactFull
+				}
+			}
+			continue
+		}
+		if !contains(RedactFields, field) {
+			return r, fmt.Errorf("unbekanntes Feld %q für --redact (erlaubt: %s|all)", field, strings.Join(RedactFields, ","))
+		}
+		if mode == RedactHash && contains(noHash, field) {
+			return r, fmt.Errorf("--redact %s: hash ist für %s nicht möglich, zu wenige mögliche Werte (full oder partial verwenden)", part, field)
+		}
+		r.Fields[field] = mode
+	}
+	return r, nil
+}
+
+func parseRedactMode(s string) (RedactMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "full":
+		return RedactFull, nil
+	case "partial":
+		return RedactPartial, nil
+	case "hash":
+		return RedactHash, nil
+	}
+	return 0, fmt.Errorf("unbekannter Schwärzungsmodus %q (erlaubt: %s)", s, strings.Join(RedactModes, "|"))
+}
+
+// apply liefert den Wert v der Feldklasse field so, wie er gedruckt wird.
+func (r Redaction) apply(field string, v []byte) string {
+	if len(v) == 0 {
+		return ""
+	}
+	switch r.Fields[field] {
+	case RedactFull:
+		return strings.Repeat("•", 8)
+	case RedactPartial:
+		runes := []rune(string(v))
+		if len(runes) <= 2*r.Keep+2 {
+			// zu kurz, der Rest ließe sich erraten
+			return strings.Repeat("•", 8)
+		}
+		return string(runes[:r.Keep]) + strings.Repeat("•", 6) + string(runes[len(runes)-r.Keep:])
+	case RedactHash:
+		if r.hasher == nil {
+			return strings.Repeat("•", 8)
+		}
+		return "Argon2id: " + r.hasher.Fingerprint(v)
+	}
+	return string(v)
+}
+
+// hashes meldet, ob eine Feldklasse als Fingerprint gedruckt wird.
+func (r Redaction) hashes() bool {
+	for _, m := range r.Fields {
+		if m == RedactHash {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldClass ordnet ein Zusatzfeld einer Feldklasse zu: Kartennummern und Prüfziffern
+// sind "cc", verdeckte Felder "concealed", alles andere bleibt ungeschwärzt.
+func fieldClass(label string, v []byte, concealed bool) string {
+	l := strings.ToLower(label)
+	switch {
+	case isCardNumber(v), strings.Contains(l, "cvv"), strings.Contains(l, "cvc"), strings.Contains(l, "prüfnummer"):
+		return "cc"
+	case concealed:
+		return "concealed"
+	}
+	return ""
+}
+
+// isCardNumber erkennt Kartennummern: 13 bis 19 Ziffern (Leerzeichen und Bindestriche
+// erlaubt) mit gültiger Luhn-Prüfsumme.
+func isCardNumber(v []byte) bool {
+	var digits []int
+	for _, c := range string(v) {
+		switch {
+		case unicode.IsDigit(c) && c <= '9':
+			digits = append(digits, int(c-'0'))
+		case c == ' ' || c == '-':
+		default:
+			return false
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
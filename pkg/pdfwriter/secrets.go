@@ -0,0 +1,139 @@
+package pdfwriter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Geheimnisse (Passwörter, TOTP, verdeckte Felder, Kartennummern) stehen in einer
+// Festbreitenschrift, damit l/1/I und O/0 auf Papier unterscheidbar sind. Optional werden
+// sie nach Zeichenklassen gefärbt (Options.PasswordColors) und Passwörter zusätzlich im
+// NATO-Alphabet buchstabiert (Options.PasswordSpelling), um sie fehlerfrei abzutippen.
+
+// charClass ordnet ein Zeichen einer der vier Farbklassen zu.
+type charClass int
+
+const (
+	classLower charClass = iota
+	classUpper
+	classDigit
+	classSymbol
+)
+
+func classOf(r rune) charClass {
+	switch {
+	case r >= '0' && r <= '9':
+		return classDigit
+	case unicode.IsUpper(r):
+		return classUpper
+	case unicode.IsLetter(r):
+		return classLower
+	}
+	return classSymbol
+}
+
+// classColors sind so gewählt, dass sie auch im Graustufendruck verschieden hell bleiben.
+var classColors = map[charClass][3]int{
+	classLower:  {0, 0, 0},
+	classUpper:  {0, 120, 60},
+	classDigit:  {0, 70, 200},
+	classSymbol: {200, 0, 0},
+}
+
+// drawColored zeichnet eine umbrochene Zeile eines Geheimnisses ab x, y in Läufen gleicher
+// Zeichenklasse. Die Festbreitenschrift ist bereits gesetzt; die Läufe liegen so, wie
+// MultiCell die ganze Zeile setzen würde.
+func (w *writer) drawColored(line string, x, y, h float64) {
+	pdf := w.pdf
+	runes := []rune(line)
+	offset := 0.0
+	for i := 0; i < len(runes); {
+		c := classOf(runes[i])
+		j := i + 1
+		for j < len(runes) && classOf(runes[j]) == c {
+			j++
+		}
+		run := string(runes[i:j])
+		rgb := classColors[c]
+		pdf.SetTextColor(rgb[0], rgb[1], rgb[2])
+		rw := pdf.GetStringWidth(run)
+		pdf.SetXY(x+offset, y)
+		pdf.CellFormat(rw, h, run, "", 0, "", false, 0, "")
+		offset += rw
+		i = j
+	}
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// writeColorLegend erklärt auf der ersten Seite die Farben der Zeichenklassen.
+func (w *writer) writeColorLegend() {
+	pdf := w.pdf
+	pdf.SetFont(w.family(), "", 9)
+	pdf.SetTextColor(80, 80, 80)
+	pdf.Write(4.5, "Zeichen in Geheimnissen: ")
+	for i, e := range []struct {
+		class         charClass
+		sample, label string
+	}{
+		{classUpper, "ABC", "Großbuchstaben"},
+		{classLower, "abc", "Kleinbuchstaben"},
+		{classDigit, "123", "Ziffern"},
+		{classSymbol, "#$%", "Sonderzeichen"},
+	} {
+		if i > 0 {
+			pdf.SetTextColor(80, 80, 80)
+			pdf.Write(4.5, ", ")
+		}
+		rgb := classColors[e.class]
+		pdf.SetFont(w.monoFont, "", 9)
+		pdf.SetTextColor(rgb[0], rgb[1], rgb[2])
+		pdf.Write(4.5, e.sample)
+		pdf.SetFont(w.family(), "", 9)
+		pdf.SetTextColor(80, 80, 80)
+		pdf.Write(4.5, " "+e.label)
+	}
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(7.5)
+	w.textFont()
+}
+
+var nato = [26]string{
+	"alfa", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india",
+	"juliett", "kilo", "lima", "mike", "november", "oscar", "papa", "quebec", "romeo",
+	"sierra", "tango", "uniform", "victor", "whiskey", "xray", "yankee", "zulu",
+}
+
+var digitNames = [10]string{"Null", "Eins", "Zwei", "Drei", "Vier", "Fünf", "Sechs", "Sieben", "Acht", "Neun"}
+
+// symbolNames sind mehrteilige Namen mit Bindestrich, damit jedes Zeichen ein Wort bleibt.
+var symbolNames = map[rune]string{
+	' ': "Leerzeichen", '!': "Ausrufezeichen", '"': "Anführungszeichen", '#': "Raute",
+	'$': "Dollar", '%': "Prozent", '&': "Und-Zeichen", '\'': "Apostroph",
+	'(': "Klammer-auf", ')': "Klammer-zu", '*': "Stern", '+': "Plus", ',': "Komma",
+	'-': "Minus", '.': "Punkt", '/': "Schrägstrich", ':': "Doppelpunkt", ';': "Semikolon",
+	'<': "Kleiner", '=': "Gleich", '>': "Größer", '?': "Fragezeichen", '@': "At",
+	'[': "Eckige-Klammer-auf", '\\': "Backslash", ']': "Eckige-Klammer-zu", '^': "Zirkumflex",
+	'_': "Unterstrich", '`': "Backtick", '{': "Geschweifte-Klammer-auf", '|': "Senkrechter-Strich",
+	'}': "Geschweifte-Klammer-zu", '~': "Tilde",
+}
+
+// spell buchstabiert s: Kleinbuchstaben klein ("kilo"), Großbuchstaben in Versalien ("KILO"),
+// Ziffern und Sonderzeichen als deutsche Namen; andere Zeichen erscheinen unverändert.
+func spell(s string) string {
+	words := make([]string, 0, len(s))
+	for _, r := range s {
+		switch lr := unicode.ToLower(r); {
+		case lr >= 'a' && lr <= 'z' && r != lr:
+			words = append(words, strings.ToUpper(nato[lr-'a']))
+		case lr >= 'a' && lr <= 'z':
+			words = append(words, nato[lr-'a'])
+		case r >= '0' && r <= '9':
+			words = append(words, digitNames[r-'0'])
+		case symbolNames[r] != "":
+			words = append(words, symbolNames[r])
+		default:
+			words = append(words, string(r))
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,92 @@
+package pdfwriter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode/qr"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/jung-kurt/gofpdf/contrib/barcode"
+
+	"github.com/example/onepw-pdf-export/pkg/fonts"
+)
+
+// ShareSheet beschreibt ein druckbares Blatt mit einem Schlüsselanteil des PDF-Passworts.
+type ShareSheet struct {
+	Share     string // kodierter Anteil (shamir.Share.String)
+	Number    int    // Nummer dieses Anteils
+	Total     int    // Anzahl aller Anteile
+	Threshold int    // Anzahl benötigter Anteile
+	Export    string // Dateiname des zugehörigen Exports
+	PageSize  string // Format wie beim Export (leer: A4), immer hochkant
+}
+
+// WriteShareSheet schreibt ein einseitiges, unverschlüsseltes PDF mit dem Anteil als Text
+// und QR-Code. Ein einzelner Anteil verrät nichts über das Passwort.
+func WriteShareSheet(path string, s ShareSheet) error {
+	size := s.PageSize
+	if size == "" {
+		size = "A4"
+	}
+	pdf := gofpdf.New("P", "mm", size, "")
+	pdf.SetTitle(fmt.Sprintf("Schlüsselanteil %d von %d", s.Number, s.Total), true)
+	pdf.SetAuthor("onepw-pdf-export", false)
+	if err := fonts.Register(pdf); err != nil {
+		return fmt.Errorf("pdfwriter: Schriften: %w", err)
+	}
+	font := fonts.FontName
+	pdf.AddPage()
+
+	pdf.SetFont(font, "", 18)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Schlüsselanteil %d von %d", s.Number, s.Total), "", 1, "", false, 0, "")
+	pdf.SetFont(font, "", 11)
+	pdf.MultiCell(0, 6, fmt.Sprintf(
+		"Export: %s\nErstellt: %s\n\nZum Öffnen des Exports werden %d der %d Anteile benötigt. "+
+			"Ein einzelner Anteil verrät nichts über das Passwort. Bewahre die Anteile getrennt auf.",
+		s.Export, time.Now().Format("2006-01-02 15:04"), s.Threshold, s.Total), "", "", false)
+	pdf.Ln(6)
+
+	key := barcode.RegisterQR(pdf, s.Share, qr.M, qr.Auto)
+	x, y := pdf.GetX(), pdf.GetY()
+	pw, _ := pdf.GetPageSize()
+	lm, _, rm, _ := pdf.GetMargins()
+	barcode.Barcode(pdf, key, x+(pw-lm-rm-80)/2, y, 80, 80, false)
+	pdf.SetY(y + 86)
+
+	pdf.SetFont(fonts.MonoName, "", 12)
+	pdf.MultiCell(0, 7, readableShare(s.Share), "1", "C", false)
+	pdf.Ln(6)
+
+	pdf.SetFont(font, "", 10)
+	pdf.MultiCell(0, 5, "Wiederherstellen:\n  onepw-pdf-export combine\nund die Anteile eingeben (Text oder QR-Inhalt). "+
+		"Groß-/Kleinschreibung und Leerzeichen spielen keine Rolle; Tippfehler erkennt die Prüfsumme am Ende.", "", "", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+// readableShare gliedert lange Abschnitte des Anteils zum Abtippen in Fünfergruppen;
+// combine ignoriert Leerzeichen.
+func readableShare(share string) string {
+	parts := strings.Split(share, "-")
+	for i, p := range parts {
+		if len(p) <= 8 {
+			continue
+		}
+		var b strings.Builder
+		for j, r := range p {
+			if j > 0 && j%5 == 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+		}
+		parts[i] = b.String()
+	}
+	return strings.Join(parts, "-")
+}
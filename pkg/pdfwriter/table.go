@@ -0,0 +1,161 @@
+package pdfwriter
+
+import (
+	"strings"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+)
+
+// Tabellenlayout (style: table): eine Zeile je Item, die Felder der Vorlage als Spalten.
+// Zellen brechen innerhalb ihrer Spalte um, die Zeile ist so hoch wie ihre längste Zelle.
+// Die Kopfzeile hängt am Seitenkopf (writer.thead) und erscheint so auf jeder Seite,
+// auch nach automatischen Umbrüchen.
+
+const (
+	cellPadX = 1.0 // Innenabstand links und rechts
+	cellPadY = 0.6 // Innenabstand oben und unten
+)
+
+// tableCell ist eine umbrochene Zelle; spell sind die Zeilen der Buchstabierung darunter.
+type tableCell struct {
+	lines  []string
+	style  string
+	size   float64
+	secret bool
+	spell  []string
+}
+
+func (w *writer) writeTable(items []model.Item) {
+	pdf := w.pdf
+	widths, err := w.tpl.columnWidths(w.contentWidth())
+	if err != nil {
+		pdf.SetError(err)
+		return
+	}
+	head := make([]tableCell, len(w.tpl.Fields))
+	for i, fd := range w.tpl.Fields {
+		head[i] = w.tableCell(tableCell{style: "B", size: w.tpl.size(fd)}, fd.label(), widths[i], 0)
+	}
+	lh := mdLineHeight(w.tpl.Font.Size)
+	header := func() {
+		w.drawTableRow(head, widths, 230)
+	}
+
+	// Kopfzeile nicht allein am Seitenende stehen lassen
+	first := w.tableHeight(head, lh) + lh + 2*cellPadY
+	w.thead = header
+	if first > w.remaining() {
+		pdf.AddPage()
+	} else {
+		header()
+	}
+	// höchstens eine Seite unter Kopf und Tabellenkopf
+	capacity := w.pageCapacity() - w.tableHeight(head, lh)
+	for i, it := range items {
+		cells := make([]tableCell, len(w.tpl.Fields))
+		for c, fd := range w.tpl.Fields {
+			cell := tableCell{size: w.tpl.size(fd)}
+			switch fd.Name {
+			case "title":
+				cell.style = "B"
+			case "password", "totp":
+				cell.secret = w.secretVisible(fd.Name)
+			}
+			v := w.fieldText(it, fd.Name)
+			cells[c] = w.tableCell(cell, v, widths[c], capacity)
+			if fd.Name == "password" && cell.secret && w.opt.PasswordSpelling && v != "" {
+				w.pdf.SetFont(w.family(), "", w.tpl.Font.Small)
+				cells[c].spell = w.split(spell(v), widths[c]-2*cellPadX)
+			}
+		}
+		if w.tableHeight(cells, lh) > w.remaining() {
+			pdf.AddPage()
+		}
+		link := pdf.AddLink()
+		pdf.SetLink(link, pdf.GetY(), pdf.PageNo())
+		w.index = append(w.index, indexEntry{Title: displayTitle(it), Page: pdf.PageNo(), Link: link})
+		gray := 255
+		if i%2 == 1 {
+			gray = 245
+		}
+		w.drawTableRow(cells, widths, gray)
+	}
+	w.thead = nil
+	w.textFont()
+	pdf.Ln(itemGap)
+}
+
+// tableCell bricht s für eine Spalte der Breite width in der Schrift von c um. Ist maxHeight
+// gesetzt und die Zelle höher als eine Seite, wird sie mit Auslassungspunkten gekürzt; die
+// Tabelle bleibt so zeilenweise.
+func (w *writer) tableCell(c tableCell, s string, width, maxHeight float64) tableCell {
+	w.cellFont(c)
+	lines := w.split(s, width-2*cellPadX)
+	w.textFont()
+	if lh := mdLineHeight(c.size); maxHeight > 0 {
+		if n := int((maxHeight - 2*cellPadY) / lh); n >= 1 && len(lines) > n {
+			lines = append(lines[:n-1], strings.TrimRight(lines[n-1], " ")+" "+w.ellipsis())
+		}
+	}
+	c.lines = lines
+	return c
+}
+
+// cellFont stellt die Schrift einer Zelle ein; Geheimnisse in der Festbreitenschrift.
+func (w *writer) cellFont(c tableCell) {
+	if c.secret {
+		w.pdf.SetFont(w.monoFont, "", c.size)
+		w.mono = true
+		return
+	}
+	w.pdf.SetFont(w.family(), c.style, c.size)
+	w.mono = false
+}
+
+// tableHeight ist die Höhe einer Tabellenzeile, mindestens eine Zeile der Grundgröße.
+func (w *writer) tableHeight(cells []tableCell, lh float64) float64 {
+	h := lh
+	for _, c := range cells {
+		h = max(h, float64(len(c.lines))*mdLineHeight(c.size)+float64(len(c.spell))*mdLineHeight(w.tpl.Font.Small))
+	}
+	return h + 2*cellPadY
+}
+
+// drawTableRow zeichnet eine Zeile mit Hintergrund gray und Trennlinie darunter.
+func (w *writer) drawTableRow(cells []tableCell, widths []float64, gray int) {
+	pdf := w.pdf
+	lm, _, _, _ := pdf.GetMargins()
+	y := pdf.GetY()
+	h := w.tableHeight(cells, mdLineHeight(w.tpl.Font.Size))
+	if gray < 255 {
+		pdf.SetFillColor(gray, gray, gray)
+		pdf.Rect(lm, y, w.contentWidth(), h, "F")
+	}
+	x := lm
+	for i, c := range cells {
+		w.cellFont(c)
+		cy, lh := y+cellPadY, mdLineHeight(c.size)
+		for _, l := range c.lines {
+			if c.secret && w.opt.PasswordColors {
+				w.drawColored(l, x+cellPadX, cy, lh)
+			} else {
+				pdf.SetXY(x+cellPadX, cy)
+				pdf.CellFormat(widths[i]-2*cellPadX, lh, l, "", 0, "", false, 0, "")
+			}
+			cy += lh
+		}
+		pdf.SetFont(w.family(), "", w.tpl.Font.Small)
+		for _, l := range c.spell {
+			pdf.SetXY(x+cellPadX, cy)
+			pdf.CellFormat(widths[i]-2*cellPadX, mdLineHeight(w.tpl.Font.Small), l, "", 0, "", false, 0, "")
+			cy += mdLineHeight(w.tpl.Font.Small)
+		}
+		x += widths[i]
+	}
+	w.textFont()
+	pdf.SetDrawColor(190, 190, 190)
+	pdf.Line(lm, y+h, lm+w.contentWidth(), y+h)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetFillColor(255, 255, 255)
+	pdf.SetXY(lm, y+h)
+}
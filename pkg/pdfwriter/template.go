@@ -0,0 +1,470 @@
+package pdfwriter
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Vorlagen legen fest, wie Items gesetzt werden: Seitenformat, Ränder, Schrift und welche
+// Felder in welcher Reihenfolge mit welcher Beschriftung erscheinen. Die eingebauten Vorlagen
+// sind als YAML eingebettet (templates/); eigene Vorlagen in YAML oder JSON erben mit
+// "base" die Werte einer eingebauten Vorlage und überschreiben nur, was sie angeben.
+//
+// style wählt die Anordnung: blocks setzt jedes Item als Block mit Label/Wert-Zeilen über
+// die ganze Breite, cards dieselben Blöcke als Karten in zwei Spalten, table eine Zeile je
+// Item mit den Feldern als Spalten und wiederholter Kopfzeile auf jeder Seite.
+
+//go:embed templates/*.yaml
+var builtinTemplates embed.FS
+
+// Templates listet die eingebauten Vorlagen.
+var Templates = []string{"compact", "detailed", "table", "cards"}
+
+// TemplateFields listet die Felder, die eine Vorlage zeigen kann. "category" ist die
+// kategoriespezifische Darstellung (Karten, Identitäten, SSH-Schlüssel, Bankkonten), in
+// Tabellen der Name der Kategorie; "fields" sind die übrigen Zusatzfelder (nicht in Tabellen),
+// "flags" Favorit und archiviert. "title" und "vault" gibt es nur als Tabellenspalten, in den
+// anderen Anordnungen stehen sie über jedem Item.
+var TemplateFields = []string{"title", "vault", "category", "username", "password", "url", "totp", "notes", "fields", "tags", "flags", "created", "updated", "id"}
+
+// Styles listet die Anordnungen (Template.Style).
+var Styles = []string{"blocks", "cards", "table"}
+
+var defaultLabels = map[string]string{
+	"title":    "Titel",
+	"vault":    "Tresor",
+	"category": "Kategorie",
+	"username": "Username",
+	"password": "Passwort",
+	"url":      "URL",
+	"totp":     "TOTP",
+	"notes":    "Notizen",
+	"tags":     "Tags",
+	"flags":    "Markierung",
+	"created":  "Erstellt",
+	"updated":  "Geändert",
+	"id":       "ID",
+}
+
+// Metadaten erscheinen ohne eigene Größe in Font.Small.
+var smallFields = map[string]bool{"tags": true, "flags": true, "created": true, "updated": true, "id": true}
+
+// pageSizes sind die Formate hochkant in mm; die Schlüssel versteht auch gofpdf.New.
+var pageSizes = map[string][2]float64{
+	"a3":     {297, 420},
+	"a4":     {210, 297},
+	"a5":     {148, 210},
+	"letter": {215.9, 279.4},
+	"legal":  {215.9, 355.6},
+}
+
+// Template ist eine Layout-Vorlage.
+type Template struct {
+	Name       string  `yaml:"name"`
+	Base       string  `yaml:"base"`  // eingebaute Vorlage als Grundlage (Standard: compact)
+	Style      string  `yaml:"style"` // blocks | cards | table (leer: blocks)
+	Page       Page    `yaml:"page"`
+	Font       Font    `yaml:"font"`
+	LabelWidth float64 `yaml:"label_width"` // Breite der Labelspalte in mm
+	Fields     []Field `yaml:"fields"`
+}
+
+// Page beschreibt Format und Ränder in mm.
+type Page struct {
+	Size        string  `yaml:"size"`        // A3 | A4 | A5 | Letter | Legal
+	Orientation string  `yaml:"orientation"` // portrait | landscape
+	Margins     Margins `yaml:"margins"`
+}
+
+type Margins struct {
+	Top    float64 `yaml:"top"`
+	Right  float64 `yaml:"right"`
+	Bottom float64 `yaml:"bottom"` // mit Fußzeile, mindestens 15
+	Left   float64 `yaml:"left"`
+}
+
+// Font wählt Schrift und Größen in pt.
+type Font struct {
+	Family string  `yaml:"family"` // leer: UTF-8-Schrift; helvetica | times | courier
+	Size   float64 `yaml:"size"`   // Feldwerte
+	Small  float64 `yaml:"small"`  // Metadaten
+}
+
+// Field ist ein Eintrag der Feldliste, in der Datei als Name ("notes") oder mit
+// Beschriftung, Größe und in Tabellen Spaltenbreite ({field: notes, label: Hinweise, size: 10}).
+type Field struct {
+	Name  string
+	Label string
+	Size  float64
+	Width float64 // Spaltenbreite in mm (nur table; 0: teilt sich den restlichen Platz)
+}
+
+func (f *Field) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		f.Name = n.Value
+		return nil
+	}
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("Zeile %d: Feld als Name oder {field: …, label: …, size: …} erwartet", n.Line)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		var err error
+		switch k.Value {
+		case "field":
+			err = v.Decode(&f.Name)
+		case "label":
+			err = v.Decode(&f.Label)
+		case "size":
+			err = v.Decode(&f.Size)
+		case "width":
+			err = v.Decode(&f.Width)
+		default:
+			return fmt.Errorf("Zeile %d: unbekannter Schlüssel %q in fields (erlaubt: field, label, size, width)", k.Line, k.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// label ist die Beschriftung des Felds in der Labelspalte.
+func (f Field) label() string {
+	if f.Label != "" {
+		return f.Label
+	}
+	return defaultLabels[f.Name]
+}
+
+// BuiltinTemplate liefert eine eingebaute Vorlage.
+func BuiltinTemplate(name string) (*Template, error) {
+	data, err := builtinTemplates.ReadFile("templates/" + strings.ToLower(name) + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unbekannte Vorlage %q (eingebaut: %s; eigene mit --template-file)", name, strings.Join(Templates, ", "))
+	}
+	t, err := parseTemplate(data, Template{})
+	if err != nil {
+		return nil, fmt.Errorf("eingebaute Vorlage %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// defaultTemplate ist die Vorlage, wenn Options.Template nicht gesetzt ist.
+func defaultTemplate() *Template {
+	t, err := BuiltinTemplate("compact")
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// LoadTemplate liest eine Vorlage aus einer YAML- oder JSON-Datei. Unbekannte Schlüssel
+// und ungültige Werte sind Fehler mit Dateiname und, soweit bekannt, Zeile.
+func LoadTemplate(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var head struct {
+		Base string `yaml:"base"`
+	}
+	_ = yaml.Unmarshal(data, &head) // Syntaxfehler meldet parseTemplate
+	if head.Base == "" {
+		head.Base = "compact"
+	}
+	base, err := BuiltinTemplate(head.Base)
+	if err != nil {
+		return nil, fmt.Errorf("%s: base: %w", path, err)
+	}
+	base.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	t, err := parseTemplate(data, *base)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// parseTemplate liest data über die Werte von t (JSON ist gültiges YAML) und prüft das Ergebnis.
+func parseTemplate(data []byte, t Template) (*Template, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&t); err != nil && !errors.Is(err, io.EOF) {
+		return nil, yamlError(err)
+	}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+var unknownKeyRe = regexp.MustCompile(`^line (\d+): field (\S+) not found in type \S+$`)
+
+// yamlError übersetzt die Meldungen von yaml.v3 für unbekannte Schlüssel und die Zeilenangaben.
+func yamlError(err error) error {
+	var te *yaml.TypeError
+	if !errors.As(err, &te) {
+		return err
+	}
+	msgs := make([]string, len(te.Errors))
+	for i, e := range te.Errors {
+		if m := unknownKeyRe.FindStringSubmatch(e); m != nil {
+			e = fmt.Sprintf("Zeile %s: unbekannter Schlüssel %q", m[1], m[2])
+		} else if rest, ok := strings.CutPrefix(e, "line "); ok {
+			e = "Zeile " + rest
+		}
+		msgs[i] = e
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+func (t *Template) validate() error {
+	if _, ok := pageSizes[strings.ToLower(t.Page.Size)]; !ok {
+		return fmt.Errorf("page.size: unbekanntes Format %q (erlaubt: A3, A4, A5, Letter, Legal)", t.Page.Size)
+	}
+	switch strings.ToLower(t.Page.Orientation) {
+	case "portrait", "landscape":
+	default:
+		return fmt.Errorf("page.orientation: %q (erlaubt: portrait, landscape)", t.Page.Orientation)
+	}
+	m := t.Page.Margins
+	if m.Top < 8 || m.Left < 0 || m.Right < 0 || m.Bottom < 15 {
+		return errors.New("page.margins: oben mindestens 8 mm, unten mindestens 15 mm (Kopf- und Fußzeile), links und rechts nicht negativ")
+	}
+	pw, ph := t.pageSize()
+	if t.LabelWidth < 10 || t.LabelWidth > pw-m.Left-m.Right-40 {
+		return fmt.Errorf("label_width: %g mm passt nicht (10 bis %g mm bei diesem Format und diesen Rändern)", t.LabelWidth, pw-m.Left-m.Right-40)
+	}
+	if ph-m.Top-m.Bottom < 60 {
+		return errors.New("page.margins: zu wenig Platz zwischen oberem und unterem Rand")
+	}
+	switch strings.ToLower(t.Font.Family) {
+	case "", "helvetica", "times", "courier":
+	default:
+		return fmt.Errorf("font.family: %q (erlaubt: leer für UTF-8, helvetica, times, courier)", t.Font.Family)
+	}
+	if !validSize(t.Font.Size) || !validSize(t.Font.Small) {
+		return errors.New("font.size und font.small: 6 bis 24 pt")
+	}
+	switch t.style() {
+	case "blocks", "cards", "table":
+	default:
+		return fmt.Errorf("style: %q (erlaubt: %s)", t.Style, strings.Join(Styles, ", "))
+	}
+	if len(t.Fields) == 0 {
+		return errors.New("fields: mindestens ein Feld angeben")
+	}
+	table := t.style() == "table"
+	seen := map[string]bool{}
+	for _, f := range t.Fields {
+		if _, ok := defaultLabels[f.Name]; !ok && f.Name != "fields" {
+			return fmt.Errorf("fields: unbekanntes Feld %q (erlaubt: %s)", f.Name, strings.Join(TemplateFields, ", "))
+		}
+		switch {
+		case table && f.Name == "fields":
+			return errors.New(`fields: "fields" gibt es nicht als Tabellenspalte`)
+		case !table && (f.Name == "title" || f.Name == "vault"):
+			return fmt.Errorf("fields: %q gibt es nur mit style: table", f.Name)
+		case !table && f.Width != 0:
+			return fmt.Errorf("fields: width von %q gibt es nur mit style: table", f.Name)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("fields: %q steht doppelt in der Liste", f.Name)
+		}
+		seen[f.Name] = true
+		if f.Size != 0 && !validSize(f.Size) {
+			return fmt.Errorf("fields: Größe von %q: 6 bis 24 pt", f.Name)
+		}
+		if f.Width < 0 || f.Width != 0 && f.Width < minColumnWidth {
+			return fmt.Errorf("fields: width von %q: mindestens %g mm", f.Name, minColumnWidth)
+		}
+	}
+	if table {
+		if _, err := t.columnWidths(pw - m.Left - m.Right); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// minColumnWidth ist die kleinste Breite einer Tabellenspalte in mm.
+const minColumnWidth = 12.0
+
+// style ist die Anordnung, leer bedeutet blocks.
+func (t *Template) style() string {
+	if t.Style == "" {
+		return "blocks"
+	}
+	return strings.ToLower(t.Style)
+}
+
+// columnWidths verteilt width auf die Tabellenspalten: feste Breiten wie angegeben, der Rest
+// zu gleichen Teilen auf Spalten ohne Breite. Haben alle eine Breite, werden sie anteilig auf
+// width gestreckt. Passen die festen Breiten nicht (etwa auf A5), werden alle Spalten
+// anteilig verkleinert, aber nicht unter minColumnWidth.
+func (t *Template) columnWidths(width float64) ([]float64, error) {
+	if n := float64(len(t.Fields)); width < n*minColumnWidth {
+		return nil, fmt.Errorf("fields: %d Spalten passen nicht in %g mm Satzbreite (je mindestens %g mm)", len(t.Fields), width, minColumnWidth)
+	}
+	out := make([]float64, len(t.Fields))
+	var fixed float64
+	auto := 0
+	for i, f := range t.Fields {
+		out[i] = f.Width
+		fixed += f.Width
+		if f.Width == 0 {
+			auto++
+		}
+	}
+	switch {
+	case auto == 0:
+		for i := range out {
+			out[i] *= width / fixed
+		}
+	case fixed+float64(auto)*minColumnWidth > width:
+		// Spalten ohne Breite zählen wie eine durchschnittliche feste, alle werden verkleinert
+		avg := fixed / float64(len(out)-auto)
+		scale := width / (fixed + float64(auto)*avg)
+		for i, f := range t.Fields {
+			if out[i] = f.Width * scale; f.Width == 0 {
+				out[i] = avg * scale
+			}
+		}
+	default:
+		for i := range out {
+			if out[i] == 0 {
+				out[i] = (width - fixed) / float64(auto)
+			}
+		}
+	}
+	return clampColumns(out), nil
+}
+
+// clampColumns hebt zu schmale Spalten auf minColumnWidth an und nimmt den Platz
+// anteilig von den breiteren; die Summe bleibt gleich.
+func clampColumns(w []float64) []float64 {
+	var missing, spare float64
+	for _, c := range w {
+		if c < minColumnWidth {
+			missing += minColumnWidth - c
+		} else {
+			spare += c - minColumnWidth
+		}
+	}
+	if missing == 0 {
+		return w
+	}
+	for i, c := range w {
+		if c < minColumnWidth {
+			w[i] = minColumnWidth
+		} else {
+			w[i] = c - (c-minColumnWidth)*missing/spare
+		}
+	}
+	return w
+}
+
+// PageOptions überschreiben die Seiteneinstellungen einer Vorlage (--page-size, --orientation,
+// --margins); Nullwerte lassen die Vorlage unverändert.
+type PageOptions struct {
+	Size        string
+	Orientation string
+	Margins     *Margins
+}
+
+// ParsePageOptions prüft die Angaben der Kommandozeile. margins sind wie in CSS ein, zwei
+// oder vier Werte in mm: "15", "10,15" (oben/unten, links/rechts) oder "10,10,20,10"
+// (oben, rechts, unten, links).
+func ParsePageOptions(size, orientation, margins string) (PageOptions, error) {
+	o := PageOptions{Size: size, Orientation: strings.ToLower(orientation)}
+	if _, ok := pageSizes[strings.ToLower(size)]; size != "" && !ok {
+		return o, fmt.Errorf("unbekanntes --page-size %q (erlaubt: A3, A4, A5, Letter, Legal)", size)
+	}
+	switch o.Orientation {
+	case "", "portrait", "landscape":
+	default:
+		return o, fmt.Errorf("unbekannte --orientation %q (erlaubt: portrait, landscape)", orientation)
+	}
+	if margins == "" {
+		return o, nil
+	}
+	var v []float64
+	for _, part := range strings.Split(margins, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), "mm")), 64)
+		if err != nil {
+			return o, fmt.Errorf("--margins %q: Zahlen in mm erwartet, z. B. 15 oder 10,15 oder 10,10,20,10", margins)
+		}
+		v = append(v, f)
+	}
+	switch len(v) {
+	case 1:
+		o.Margins = &Margins{Top: v[0], Right: v[0], Bottom: v[0], Left: v[0]}
+	case 2:
+		o.Margins = &Margins{Top: v[0], Right: v[1], Bottom: v[0], Left: v[1]}
+	case 4:
+		o.Margins = &Margins{Top: v[0], Right: v[1], Bottom: v[2], Left: v[3]}
+	default:
+		return o, fmt.Errorf("--margins %q: ein, zwei oder vier Werte (oben, rechts, unten, links)", margins)
+	}
+	return o, nil
+}
+
+// ApplyPage übernimmt o in die Vorlage und prüft das Ergebnis erneut, da z. B. die
+// Labelspalte oder feste Tabellenspalten auf einer kleineren Seite nicht mehr passen.
+func (t *Template) ApplyPage(o PageOptions) error {
+	if o.Size != "" {
+		t.Page.Size = o.Size
+	}
+	if o.Orientation != "" {
+		t.Page.Orientation = o.Orientation
+	}
+	if o.Margins != nil {
+		t.Page.Margins = *o.Margins
+	}
+	if err := t.validate(); err != nil {
+		return fmt.Errorf("Vorlage %s mit --page-size/--orientation/--margins: %w", t.Name, err)
+	}
+	return nil
+}
+
+func validSize(s float64) bool { return s >= 6 && s <= 24 }
+
+// has meldet, ob die Vorlage das Feld name zeigt.
+func (t *Template) has(name string) bool {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// pageSize liefert Breite und Höhe der Seite in mm unter Berücksichtigung der Ausrichtung.
+func (t *Template) pageSize() (w, h float64) {
+	s := pageSizes[strings.ToLower(t.Page.Size)]
+	if strings.EqualFold(t.Page.Orientation, "landscape") {
+		return s[1], s[0]
+	}
+	return s[0], s[1]
+}
+
+// size ist die Schriftgröße eines Felds.
+func (t *Template) size(f Field) float64 {
+	switch {
+	case f.Size != 0:
+		return f.Size
+	case smallFields[f.Name]:
+		return t.Font.Small
+	}
+	return t.Font.Size
+}
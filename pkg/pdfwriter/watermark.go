@@ -0,0 +1,49 @@
+package pdfwriter
+
+import "math"
+
+// watermark legt opt.Watermark diagonal und halbtransparent über die ganze Seite.
+// Die Schriftgröße wird so gewählt, dass der Text in die Seitendiagonale passt.
+func (w *writer) watermark() {
+	if w.opt.Watermark == "" {
+		return
+	}
+	pdf := w.pdf
+	pw, ph := pdf.GetPageSize()
+	diag := math.Hypot(pw, ph) * 0.8
+	angle := math.Atan2(ph, pw) * 180 / math.Pi
+
+	size := 60.0
+	pdf.SetFont("", "B", size)
+	if sw := pdf.GetStringWidth(w.opt.Watermark); sw > diag {
+		size *= diag / sw
+		pdf.SetFontSize(size)
+	}
+	sw := pdf.GetStringWidth(w.opt.Watermark)
+
+	pdf.SetAlpha(0.15, "Normal")
+	pdf.SetTextColor(120, 120, 120)
+	pdf.TransformBegin()
+	pdf.TransformRotate(angle, pw/2, ph/2)
+	// Grundlinie etwa um eine halbe Versalhöhe absenken, damit der Text mittig sitzt
+	pdf.Text(pw/2-sw/2, ph/2+size*0.35/2.83, w.opt.Watermark)
+	pdf.TransformEnd()
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetAlpha(1, "Normal")
+}
+
+// banner druckt die Einstufung als roten Balken an den oberen bzw. unteren Seitenrand.
+func (w *writer) banner(y float64) {
+	if w.opt.Classification == "" {
+		return
+	}
+	pdf := w.pdf
+	lm, _, _, _ := pdf.GetMargins()
+	pdf.SetFillColor(190, 20, 20)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("", "B", 9)
+	pdf.SetXY(lm, y)
+	pdf.CellFormat(w.contentWidth(), 5, w.opt.Classification, "", 0, "C", true, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFillColor(255, 255, 255)
+}
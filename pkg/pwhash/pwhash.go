@@ -0,0 +1,203 @@
+// Package pwhash erzeugt Passwort-Fingerprints für Prüfausdrucke: Ein gesalzener,
+// absichtlich langsamer Hash (Argon2id) belegt, welches Passwort gespeichert ist, ohne es
+// offenzulegen. Das Salz gilt für einen ganzen Export; gleiche Passwörter haben dort den
+// gleichen Fingerprint, was Wiederverwendung sichtbar macht.
+package pwhash
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Version kennzeichnet Format und Parameter im Manifest.
+const Version = 1
+
+// Standardparameter nach OWASP-Empfehlung für Argon2id (19 MiB, 2 Durchläufe).
+const (
+	defaultTime    = 2
+	defaultMemory  = 19 * 1024
+	defaultThreads = 1
+	fingerprintLen = 10 // Bytes, 16 Base32-Zeichen
+)
+
+// Obergrenzen für Parameter aus Dateien (Snapshot, Manifest), damit eine manipulierte
+// Datei nicht Speicher oder Rechenzeit erschöpft, bevor das Passwort geprüft ist.
+const (
+	maxTime   = 16
+	maxMemory = 256 * 1024 // KiB
+	minSalt   = 8
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Params sind Salz und Kostenparameter eines Exports.
+type Params struct {
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+// NewParams erzeugt ein zufälliges Salz mit den Standardkosten.
+func NewParams() (Params, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return Params{}, err
+	}
+	return DefaultParams(salt), nil
+}
+
+// DefaultParams liefert die Standardkosten mit gegebenem Salz, z. B. für die Prüfung
+// eines Ausdrucks ohne PDF.
+func DefaultParams(salt []byte) Params {
+	return Params{Salt: salt, Time: defaultTime, Memory: defaultMemory, Threads: defaultThreads}
+}
+
+// Check prüft Parameter aus einer Datei, bevor damit ein Schlüssel abgeleitet wird;
+// argon2.IDKey bricht bei Time oder Threads 0 mit panic ab.
+func (p Params) Check() error {
+	switch {
+	case len(p.Salt) < minSalt:
+		return fmt.Errorf("pwhash: Salz zu kurz (%d Bytes, mindestens %d)", len(p.Salt), minSalt)
+	case p.Time < 1 || p.Time > maxTime:
+		return fmt.Errorf("pwhash: ungültige Durchläufe %d (1–%d)", p.Time, maxTime)
+	case p.Threads < 1:
+		return errors.New("pwhash: ungültige Threads 0 (1–255)")
+	case p.Memory < 8*uint32(p.Threads) || p.Memory > maxMemory:
+		return fmt.Errorf("pwhash: ungültiger Speicher %d KiB (%d–%d)", p.Memory, 8*uint32(p.Threads), maxMemory)
+	}
+	return nil
+}
+
+// SaltString liefert das Salz zum Abdruck, z. B. für die Prüfung ohne PDF.
+func (p Params) SaltString() string {
+	return b32.EncodeToString(p.Salt)
+}
+
+// ParseSalt liest ein mit SaltString gedrucktes Salz; Leerzeichen und Bindestriche zählen nicht.
+func ParseSalt(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+	salt, err := b32.DecodeString(s)
+	if err != nil || len(salt) == 0 {
+		return nil, errors.New("pwhash: ungültiges Salz")
+	}
+	return salt, nil
+}
+
+// Fingerprint berechnet den Fingerprint von pw, z. B. "K7QF-2MZD-WX4A-PL3B".
+func (p Params) Fingerprint(pw []byte) string {
+	sum := argon2.IDKey(pw, p.Salt, p.Time, p.Memory, p.Threads, fingerprintLen)
+	s := b32.EncodeToString(sum)
+	return s[0:4] + "-" + s[4:8] + "-" + s[8:12] + "-" + s[12:16]
+}
+
+// Key leitet aus pw einen Schlüssel mit n Bytes ab, z. B. für verschlüsselte Snapshots.
+func (p Params) Key(pw []byte, n uint32) []byte {
+	return argon2.IDKey(pw, p.Salt, p.Time, p.Memory, p.Threads, n)
+}
+
+// Equal vergleicht zwei Fingerprints ohne Rücksicht auf Schreibweise und Trennzeichen.
+func Equal(a, b string) bool {
+	norm := strings.NewReplacer(" ", "", "-", "")
+	return strings.EqualFold(norm.Replace(a), norm.Replace(b))
+}
+
+// Hasher berechnet Fingerprints eines Exports und merkt sich bereits berechnete,
+// da Messen und Zeichnen ein Item zweimal durchlaufen.
+type Hasher struct {
+	Params
+	cache map[[32]byte]string
+}
+
+// NewHasher erzeugt einen Hasher mit frischem Salz.
+func NewHasher() (*Hasher, error) {
+	p, err := NewParams()
+	if err != nil {
+		return nil, err
+	}
+	return &Hasher{Params: p, cache: map[[32]byte]string{}}, nil
+}
+
+// Fingerprint wie Params.Fingerprint, mit Cache.
+func (h *Hasher) Fingerprint(pw []byte) string {
+	key := sha256.Sum256(append(append([]byte{}, h.Salt...), pw...))
+	if fp, ok := h.cache[key]; ok {
+		return fp
+	}
+	fp := h.Params.Fingerprint(pw)
+	h.cache[key] = fp
+	return fp
+}
+
+// Summary beschreibt Länge und Zeichenklassen von pw, z. B. "16 Zeichen: A-Z a-z 0-9 #".
+func Summary(pw []byte) string {
+	var upper, lower, digit, other bool
+	n := 0
+	for _, r := range string(pw) {
+		n++
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	var classes []string
+	for _, c := range []struct {
+		on   bool
+		name string
+	}{{upper, "A-Z"}, {lower, "a-z"}, {digit, "0-9"}, {other, "#"}} {
+		if c.on {
+			classes = append(classes, c.name)
+		}
+	}
+	return fmt.Sprintf("%d Zeichen: %s", n, strings.Join(classes, " "))
+}
+
+// Manifest wird als Datei in das PDF eingebettet, damit verify einen Kandidaten
+// gegen alle Items eines Exports prüfen kann.
+type Manifest struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Params
+	Items []ManifestItem `json:"items"`
+}
+
+// ManifestItem ordnet einem Item seinen Fingerprint zu.
+type ManifestItem struct {
+	Title       string `json:"title"`
+	Vault       string `json:"vault,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// ManifestName ist der Dateiname des eingebetteten Manifests.
+const ManifestName = "passwort-fingerprints.json"
+
+// Match liefert alle Items, deren Fingerprint zu pw passt.
+func (m Manifest) Match(pw []byte) ([]ManifestItem, error) {
+	if m.Version != Version || m.KDF != "argon2id" {
+		return nil, fmt.Errorf("pwhash: Manifest-Version %d (%s) wird nicht unterstützt", m.Version, m.KDF)
+	}
+	if err := m.Params.Check(); err != nil {
+		return nil, err
+	}
+	fp := m.Params.Fingerprint(pw)
+	var out []ManifestItem
+	for _, it := range m.Items {
+		if Equal(it.Fingerprint, fp) {
+			out = append(out, it)
+		}
+	}
+	return out, nil
+}
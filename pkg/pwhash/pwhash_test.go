@@ -0,0 +1,201 @@
+package pwhash
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var fingerprintRe = regexp.MustCompile(`^[A-Z2-7]{4}(-[A-Z2-7]{4}){3}$`)
+
+func testSalt() []byte { return []byte("0123456789abcdef") }
+
+func TestFingerprint(t *testing.T) {
+	p := DefaultParams(testSalt())
+	other := DefaultParams([]byte("fedcba9876543210"))
+	tests := []struct {
+		a, b Params
+		pa   string
+		pb   string
+		same bool
+	}{
+		{p, p, "geheim", "geheim", true},
+		{p, p, "geheim", "Geheim", false},
+		{p, p, "geheim", "geheim ", false},
+		{p, other, "geheim", "geheim", false},
+		{p, Params{Salt: testSalt(), Time: 3, Memory: defaultMemory, Threads: 1}, "geheim", "geheim", false},
+	}
+	for _, tt := range tests {
+		fa, fb := tt.a.Fingerprint([]byte(tt.pa)), tt.b.Fingerprint([]byte(tt.pb))
+		for _, fp := range []string{fa, fb} {
+			if !fingerprintRe.MatchString(fp) {
+				t.Errorf("Fingerprint %q hat nicht das Format XXXX-XXXX-XXXX-XXXX", fp)
+			}
+		}
+		if (fa == fb) != tt.same {
+			t.Errorf("%q/%q: %s und %s, gleich erwartet: %v", tt.pa, tt.pb, fa, fb, tt.same)
+		}
+	}
+}
+
+func TestHasher(t *testing.T) {
+	h, err := NewHasher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Salt) != 16 || h.Check() != nil {
+		t.Fatalf("NewHasher: Salz %d Bytes, Check %v", len(h.Salt), h.Check())
+	}
+	h2, err := NewHasher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(h.Salt, h2.Salt) {
+		t.Error("zwei Hasher mit gleichem Salz")
+	}
+	pw := []byte("korrekt pferd")
+	fp := h.Fingerprint(pw)
+	if again := h.Fingerprint(pw); again != fp || h.Params.Fingerprint(pw) != fp {
+		t.Errorf("Cache liefert %s, Params %s, erwartet %s", again, h.Params.Fingerprint(pw), fp)
+	}
+	// verify ohne PDF: gedrucktes Salz und Standardkosten ergeben denselben Fingerprint
+	salt, err := ParseSalt(h.SaltString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultParams(salt).Fingerprint(pw); !Equal(got, fp) {
+		t.Errorf("mit gedrucktem Salz %s, erwartet %s", got, fp)
+	}
+}
+
+func TestParseSalt(t *testing.T) {
+	p := DefaultParams(testSalt())
+	s := p.SaltString()
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{s, true},
+		{strings.ToLower(s), true},
+		{s[:4] + "-" + s[4:8] + " " + s[8:], true},
+		{"", false},
+		{"----", false},
+		{"ABC1", false}, // 1 gibt es in Base32 nicht
+		{s + "=", false},
+	}
+	for _, tt := range tests {
+		salt, err := ParseSalt(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseSalt(%q): %v", tt.in, err)
+			continue
+		}
+		if tt.ok && !bytes.Equal(salt, p.Salt) {
+			t.Errorf("ParseSalt(%q) = %x, erwartet %x", tt.in, salt, p.Salt)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"K7QF-2MZD-WX4A-PL3B", "K7QF-2MZD-WX4A-PL3B", true},
+		{"K7QF-2MZD-WX4A-PL3B", "k7qf 2mzd wx4a pl3b", true},
+		{"K7QF-2MZD-WX4A-PL3B", "K7QF2MZDWX4APL3B", true},
+		{"K7QF-2MZD-WX4A-PL3B", "K7QF-2MZD-WX4A-PL3C", false},
+		{"K7QF-2MZD-WX4A-PL3B", "K7QF-2MZD-WX4A", false},
+	}
+	for _, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v", tt.a, tt.b, got)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ok := DefaultParams(testSalt())
+	tests := []struct {
+		name string
+		edit func(*Params)
+		msg  string
+	}{
+		{"Standard", func(*Params) {}, ""},
+		{"kein Salz", func(p *Params) { p.Salt = nil }, "Salz zu kurz"},
+		{"kurzes Salz", func(p *Params) { p.Salt = p.Salt[:7] }, "Salz zu kurz"},
+		{"Time 0", func(p *Params) { p.Time = 0 }, "Durchläufe"},
+		{"Time zu groß", func(p *Params) { p.Time = maxTime + 1 }, "Durchläufe"},
+		{"Threads 0", func(p *Params) { p.Threads = 0 }, "Threads"},
+		{"Threads 255", func(p *Params) { p.Threads = 255; p.Memory = 8 * 255 }, ""},
+		{"Speicher zu klein", func(p *Params) { p.Threads = 4; p.Memory = 31 }, "Speicher"},
+		{"Speicher zu groß", func(p *Params) { p.Memory = maxMemory + 1 }, "Speicher"},
+	}
+	for _, tt := range tests {
+		p := ok
+		tt.edit(&p)
+		err := p.Check()
+		switch {
+		case tt.msg == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.msg != "" && (err == nil || !strings.Contains(err.Error(), tt.msg)):
+			t.Errorf("%s: %v, erwartet %q", tt.name, err, tt.msg)
+		}
+	}
+}
+
+func TestManifestMatch(t *testing.T) {
+	p := DefaultParams(testSalt())
+	m := Manifest{Version: Version, KDF: "argon2id", Params: p, Items: []ManifestItem{
+		{Title: "Bank", Fingerprint: p.Fingerprint([]byte("eins"))},
+		{Title: "Mail", Fingerprint: strings.ToLower(p.Fingerprint([]byte("zwei")))},
+		{Title: "Shop", Fingerprint: p.Fingerprint([]byte("eins"))},
+	}}
+	tests := []struct {
+		pw   string
+		want []string
+	}{
+		{"eins", []string{"Bank", "Shop"}},
+		{"zwei", []string{"Mail"}},
+		{"drei", nil},
+	}
+	for _, tt := range tests {
+		got, err := m.Match([]byte(tt.pw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, it := range got {
+			titles = append(titles, it.Title)
+		}
+		if strings.Join(titles, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Match(%q) = %v, erwartet %v", tt.pw, titles, tt.want)
+		}
+	}
+
+	bad := m
+	bad.Version = 2
+	if _, err := bad.Match([]byte("eins")); err == nil {
+		t.Error("Manifest-Version 2 akzeptiert")
+	}
+	bad = m
+	bad.Threads = 0
+	if _, err := bad.Match([]byte("eins")); err == nil {
+		t.Error("Manifest mit Threads 0 akzeptiert")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct{ pw, want string }{
+		{"", "0 Zeichen: "},
+		{"abc", "3 Zeichen: a-z"},
+		{"Abc1#", "5 Zeichen: A-Z a-z 0-9 #"},
+		{"Äöü ß", "5 Zeichen: A-Z a-z #"},
+		{"1234", "4 Zeichen: 0-9"},
+	}
+	for _, tt := range tests {
+		if got := Summary([]byte(tt.pw)); got != tt.want {
+			t.Errorf("Summary(%q) = %q, erwartet %q", tt.pw, got, tt.want)
+		}
+	}
+}
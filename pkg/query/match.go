@@ -0,0 +1,236 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+)
+
+// Expr ist ein Knoten des Prädikatbaums.
+type Expr interface {
+	Match(it model.Item) bool
+}
+
+// Fields listet die Felder der Filtersprache, z. B. für Hilfetexte.
+var Fields = []string{"vault", "category", "tag", "title", "user", "url", "notes", "id", "has", "is", "created", "updated"}
+
+type all struct{}
+
+func (all) Match(model.Item) bool { return true }
+
+type and []Expr
+
+func (a and) Match(it model.Item) bool {
+	for _, e := range a {
+		if !e.Match(it) {
+			return false
+		}
+	}
+	return true
+}
+
+type or []Expr
+
+func (o or) Match(it model.Item) bool {
+	for _, e := range o {
+		if e.Match(it) {
+			return true
+		}
+	}
+	return false
+}
+
+type not struct{ e Expr }
+
+func (n not) Match(it model.Item) bool { return !n.e.Match(it) }
+
+// text sucht wie --search ohne Rücksicht auf Groß-/Kleinschreibung in Titel,
+// Username, URLs und Tags.
+type text string
+
+func (t text) Match(it model.Item) bool {
+	q := strings.ToLower(string(t))
+	fields := []string{it.Title, it.Username}
+	fields = append(fields, it.URLs...)
+	fields = append(fields, it.Tags...)
+	for _, v := range fields {
+		if strings.Contains(strings.ToLower(v), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// pred ist ein Feldvergleich.
+type pred func(it model.Item) bool
+
+func (p pred) Match(it model.Item) bool { return p(it) }
+
+// newField baut das Prädikat für key op val. Die Fehlermeldung landet im *Error des Parsers.
+func newField(key, op, val string) (Expr, error) {
+	switch key {
+	case "created", "updated":
+		return timePred(key, op, val)
+	}
+	if op != ":" && op != "=" {
+		return nil, fmt.Errorf("%q kennt nur \":\", Vergleiche gibt es für created und updated", key)
+	}
+	m := stringMatcher(val)
+	switch key {
+	case "vault":
+		return pred(func(it model.Item) bool { return m.equal(it.Vault) }), nil
+	case "category", "cat":
+		return pred(func(it model.Item) bool { return m.equal(it.Category) }), nil
+	case "tag":
+		return pred(func(it model.Item) bool {
+			if !m.glob {
+				return it.HasTag(val)
+			}
+			for _, t := range it.Tags {
+				if m.equal(t) {
+					return true
+				}
+			}
+			return false
+		}), nil
+	case "title":
+		return pred(func(it model.Item) bool { return m.contains(it.Title) }), nil
+	case "user", "username":
+		return pred(func(it model.Item) bool { return m.contains(it.Username) }), nil
+	case "notes":
+		return pred(func(it model.Item) bool { return m.contains(it.Notes) }), nil
+	case "id":
+		return pred(func(it model.Item) bool { return m.equal(it.ID) }), nil
+	case "url":
+		// *.example.com passt auch auf example.com selbst
+		apex := strings.TrimPrefix(m.val, "*.")
+		return pred(func(it model.Item) bool {
+			for _, u := range it.URLs {
+				host := strings.ToLower(hostname(u))
+				if m.contains(u) || m.glob && (m.equal(host) || host == apex) {
+					return true
+				}
+			}
+			return false
+		}), nil
+	case "has":
+		return hasPred(val)
+	case "is":
+		switch strings.ToLower(val) {
+		case "favorite", "fav", "favorit":
+			return pred(func(it model.Item) bool { return it.Favorite }), nil
+		case "archived", "archiviert":
+			return pred(func(it model.Item) bool { return it.Archived }), nil
+		}
+		return nil, fmt.Errorf("unbekannter Wert is:%s (erlaubt: favorite, archived)", val)
+	}
+	return nil, fmt.Errorf("unbekanntes Feld %q (erlaubt: %s)", key, strings.Join(Fields, ", "))
+}
+
+func hasPred(val string) (Expr, error) {
+	var f func(it model.Item) bool
+	switch strings.ToLower(val) {
+	case "password":
+		f = func(it model.Item) bool { return len(it.Password) > 0 }
+	case "totp", "otp":
+		f = func(it model.Item) bool { return len(it.TOTP) > 0 }
+	case "url":
+		f = func(it model.Item) bool { return len(it.URLs) > 0 }
+	case "username", "user":
+		f = func(it model.Item) bool { return it.Username != "" }
+	case "notes":
+		f = func(it model.Item) bool { return it.Notes != "" }
+	case "tag", "tags":
+		f = func(it model.Item) bool { return len(it.Tags) > 0 }
+	case "fields":
+		f = func(it model.Item) bool { return len(it.RawFields) > 0 }
+	default:
+		return nil, fmt.Errorf("unbekannter Wert has:%s (erlaubt: password, totp, url, username, notes, tags, fields)", val)
+	}
+	return pred(f), nil
+}
+
+// matcher vergleicht ohne Rücksicht auf Groß-/Kleinschreibung; enthält der Wert * oder ?,
+// gilt er als Muster für den ganzen Wert.
+type matcher struct {
+	val  string
+	glob bool
+}
+
+func stringMatcher(val string) matcher {
+	return matcher{val: strings.ToLower(val), glob: strings.ContainsAny(val, "*?")}
+}
+
+func (m matcher) equal(s string) bool {
+	s = strings.ToLower(s)
+	if m.glob {
+		ok, _ := path.Match(m.val, s)
+		return ok
+	}
+	return s == m.val
+}
+
+func (m matcher) contains(s string) bool {
+	if m.glob {
+		return m.equal(s)
+	}
+	return strings.Contains(strings.ToLower(s), m.val)
+}
+
+func hostname(raw string) string {
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// timePred vergleicht created/updated mit einem Datum (2006-01-02) oder Zeitpunkt (RFC 3339).
+// Ein Datum steht für den ganzen Tag in lokaler Zeit: ">" heißt nach diesem Tag, ">=" ab diesem Tag.
+// Items ohne Zeitstempel passen nie.
+func timePred(key, op, val string) (Expr, error) {
+	from, to, err := parseWhen(val)
+	if err != nil {
+		return nil, err
+	}
+	at := func(it model.Item) time.Time { return it.Updated }
+	if key == "created" {
+		at = func(it model.Item) time.Time { return it.Created }
+	}
+	var cmp func(t time.Time) bool
+	switch op {
+	case ">":
+		cmp = func(t time.Time) bool { return !t.Before(to) }
+	case ">=":
+		cmp = func(t time.Time) bool { return !t.Before(from) }
+	case "<":
+		cmp = func(t time.Time) bool { return t.Before(from) }
+	case "<=":
+		cmp = func(t time.Time) bool { return t.Before(to) }
+	default: // ":" und "="
+		cmp = func(t time.Time) bool { return !t.Before(from) && t.Before(to) }
+	}
+	return pred(func(it model.Item) bool {
+		t := at(it)
+		return !t.IsZero() && cmp(t)
+	}), nil
+}
+
+// parseWhen liefert das halboffene Intervall [from, to), für das val steht.
+func parseWhen(val string) (from, to time.Time, err error) {
+	if t, err := time.ParseInLocation("2006-01-02", val, time.Local); err == nil {
+		return t, t.AddDate(0, 0, 1), nil
+	}
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, t.Add(time.Second), nil
+	}
+	return time.Time{}, time.Time{}, errors.New("Datum erwartet, z. B. 2024-01-31 oder 2024-01-31T12:00:00Z")
+}
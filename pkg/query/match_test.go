@@ -0,0 +1,112 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/secret"
+)
+
+func testItems() map[string]model.Item {
+	day := func(s string) time.Time {
+		t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	return map[string]model.Item{
+		"bank": {
+			ID: "b1", Title: "Meine Bank Online", Category: "LOGIN", Vault: "Privat",
+			Username: "anna", Password: secret.New("pw"), TOTP: secret.New("otpauth://x"),
+			URLs: []string{"https://login.sparkasse.de/konto"}, Tags: []string{"Finanzen/Bank"},
+			Created: day("2023-05-01 09:00"), Updated: day("2024-01-01 12:00"), Favorite: true,
+		},
+		"mail": {
+			ID: "m1", Title: "Mail", Category: "LOGIN", Vault: "Arbeit", Username: "a.muster",
+			Password: secret.New("pw2"), URLs: []string{"mail.example.com"}, Tags: []string{"alt"},
+			Notes: "Zugang über VPN", Updated: day("2024-01-02 08:00"), Archived: true,
+		},
+		"note": {Title: "Notiz", Category: "SECURE_NOTE", Vault: "Privat", Notes: "Nur Text"},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		q    string
+		want []string // passende Items, sortiert
+	}{
+		{"", []string{"bank", "mail", "note"}},
+		// ohne Feld: Titel, Username, URLs und Tags, nicht Notizen
+		{"bank", []string{"bank"}},
+		{"SPARKASSE", []string{"bank"}},
+		{"finanzen", []string{"bank"}},
+		{"vpn", nil},
+		{"vault:privat", []string{"bank", "note"}},
+		{"vault:priv", nil},
+		{"vault:priv*", []string{"bank", "note"}},
+		{"category:login -vault:arbeit", []string{"bank"}},
+		{"cat:secure_note", []string{"note"}},
+		{`title:"bank online"`, []string{"bank"}},
+		{`title:"bank offline"`, nil},
+		{"user:muster", []string{"mail"}},
+		{"notes:vpn", []string{"mail"}},
+		{"id:M1", []string{"mail"}},
+		// verschachtelte Tags zählen für den Elterntag
+		{"tag:finanzen", []string{"bank"}},
+		{"tag:finanzen/bank", []string{"bank"}},
+		{"tag:bank", nil},
+		{"tag:*/bank", []string{"bank"}},
+		{"-tag:alt", []string{"bank", "note"}},
+		{"url:sparkasse", []string{"bank"}},
+		{"url:*.example.com", []string{"mail"}},
+		{"url:*.sparkasse.de", []string{"bank"}},
+		{"has:totp", []string{"bank"}},
+		{"has:password", []string{"bank", "mail"}},
+		{"-has:url", []string{"note"}},
+		{"has:tags has:notes", []string{"mail"}},
+		{"is:favorite", []string{"bank"}},
+		{"is:archived", []string{"mail"}},
+		// Datum steht für den ganzen Tag; Items ohne Zeitstempel passen nie
+		{"updated:2024-01-01", []string{"bank"}},
+		{"updated>2024-01-01", []string{"mail"}},
+		{"updated>=2024-01-01", []string{"bank", "mail"}},
+		{"updated<2024-01-02", []string{"bank"}},
+		{"updated<=2024-01-02", []string{"bank", "mail"}},
+		{"created<2024-01-01", []string{"bank"}},
+		{"-created<2024-01-01", []string{"mail", "note"}},
+		// Vorrang: UND vor ODER, Klammern und Verneinung
+		{"vault:arbeit OR vault:privat category:login", []string{"bank", "mail"}},
+		{"(vault:arbeit OR vault:privat) category:secure_note", []string{"note"}},
+		{"vault:arbeit | is:favorite", []string{"bank", "mail"}},
+		{"NOT (vault:arbeit OR has:totp)", []string{"note"}},
+		{"-vault:privat -vault:arbeit", nil},
+	}
+	items := testItems()
+	for _, tt := range tests {
+		e, err := Parse(tt.q)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.q, err)
+			continue
+		}
+		var got []string
+		for _, name := range []string{"bank", "mail", "note"} {
+			if e.Match(items[name]) {
+				got = append(got, name)
+			}
+		}
+		if len(got) != len(tt.want) || len(got) > 0 && !equalNames(got, tt.want) {
+			t.Errorf("%q passt auf %v, erwartet %v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func equalNames(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
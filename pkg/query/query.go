@@ -0,0 +1,243 @@
+// Package query implementiert die Filtersprache für --filter, z. B.
+//
+//	vault:Privat category:login tag:bank -tag:alt updated>2024-01-01 url:*.example.com has:totp
+//
+// Begriffe werden mit UND verknüpft; OR (oder |) verbindet Alternativen, "-" oder NOT
+// verneint, Klammern gruppieren. Werte mit Leerzeichen stehen in Anführungszeichen.
+// Ein Begriff ohne Feld sucht wie --search in Titel, Username, URLs und Tags.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Error meldet einen Syntaxfehler und zeigt auf das fehlerhafte Token.
+type Error struct {
+	Query string
+	Pos   int // Byte-Offset des Tokens in Query
+	Len   int
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	n := e.Len
+	if n < 1 {
+		n = 1
+	}
+	// Markierung unter dem Token; Position in Zeichen, nicht Bytes
+	col := len([]rune(e.Query[:e.Pos]))
+	width := len([]rune(e.Query[e.Pos:min(e.Pos+n, len(e.Query))]))
+	if width < 1 {
+		width = 1
+	}
+	return fmt.Sprintf("Filter: %s\n  %s\n  %s%s", e.Msg, e.Query, strings.Repeat(" ", col), strings.Repeat("^", width))
+}
+
+type tokKind int
+
+const (
+	tokTerm tokKind = iota
+	tokLParen
+	tokRParen
+	tokOr
+	tokNot
+	tokEOF
+)
+
+type token struct {
+	kind tokKind
+	text string // bei tokTerm ohne Anführungszeichen
+	pos  int
+	end  int
+}
+
+// lex zerlegt q in Tokens. Anführungszeichen dürfen mitten im Begriff stehen
+// (title:"Meine Bank"), Klammern und Leerzeichen darin zählen nicht.
+func lex(q string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(q) {
+		c := q[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			toks = append(toks, token{kind: tokLParen, pos: i, end: i + 1})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tokRParen, pos: i, end: i + 1})
+			i++
+		case c == '|':
+			toks = append(toks, token{kind: tokOr, pos: i, end: i + 1})
+			i++
+		case c == '-' && (i+1 < len(q) && q[i+1] != ' '):
+			toks = append(toks, token{kind: tokNot, pos: i, end: i + 1})
+			i++
+		default:
+			start := i
+			var b strings.Builder
+			quoted := false
+			for i < len(q) {
+				c := q[i]
+				if c == '"' {
+					quoted = !quoted
+					i++
+					continue
+				}
+				if !quoted && (c == ' ' || c == '\t' || c == '\n' || c == '(' || c == ')' || c == '|') {
+					break
+				}
+				b.WriteByte(c)
+				i++
+			}
+			if quoted {
+				return nil, &Error{Query: q, Pos: start, Len: i - start, Msg: "Anführungszeichen nicht geschlossen"}
+			}
+			t := token{kind: tokTerm, text: b.String(), pos: start, end: i}
+			switch q[start:i] {
+			case "OR":
+				t.kind = tokOr
+			case "AND":
+				continue // UND ist ohnehin der Standard
+			case "NOT":
+				t.kind = tokNot
+			}
+			toks = append(toks, t)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(q), end: len(q)}), nil
+}
+
+type parser struct {
+	q    string
+	toks []token
+	i    int
+}
+
+// Parse übersetzt q in einen Ausdruck. Ein leerer Filter passt auf alle Items.
+func Parse(q string) (Expr, error) {
+	toks, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{q: q, toks: toks}
+	if p.peek().kind == tokEOF {
+		return all{}, nil
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errAt(t, "unerwartetes Token")
+	}
+	return e, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+func (p *parser) next() token { t := p.toks[p.i]; p.i++; return t }
+
+func (p *parser) errAt(t token, msg string) error {
+	return &Error{Query: p.q, Pos: t.pos, Len: t.end - t.pos, Msg: msg}
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	alts := []Expr{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		e, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		alts = append(alts, e)
+	}
+	if len(alts) == 1 {
+		return first, nil
+	}
+	return or(alts), nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	var parts []Expr
+	for {
+		switch t := p.peek(); t.kind {
+		case tokEOF, tokRParen, tokOr:
+			if len(parts) == 0 {
+				return nil, p.errAt(t, "Begriff erwartet")
+			}
+			if len(parts) == 1 {
+				return parts[0], nil
+			}
+			return and(parts), nil
+		}
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, e)
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	switch t := p.next(); t.kind {
+	case tokNot:
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return not{e}, nil
+	case tokLParen:
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokRParen {
+			return nil, p.errAt(t, "Klammer nicht geschlossen")
+		}
+		return e, nil
+	case tokTerm:
+		return p.parseTerm(t)
+	default:
+		return nil, p.errAt(t, "Begriff erwartet")
+	}
+}
+
+// parseTerm zerlegt "feld:wert" bzw. "feld>wert" und baut das passende Prädikat.
+func (p *parser) parseTerm(t token) (Expr, error) {
+	key, op, val := splitTerm(t.text)
+	if op == "" || op == ":" && strings.HasPrefix(val, "//") {
+		// ohne Feld, auch eine nackte URL wie https://example.com
+		return text(t.text), nil
+	}
+	if val == "" {
+		return nil, p.errAt(t, fmt.Sprintf("Wert für %q fehlt", key))
+	}
+	e, err := newField(strings.ToLower(key), op, val)
+	if err != nil {
+		return nil, p.errAt(t, err.Error())
+	}
+	return e, nil
+}
+
+// splitTerm trennt am ersten Operator hinter einem Feldnamen aus Buchstaben.
+func splitTerm(s string) (key, op, val string) {
+	i := 0
+	for i < len(s) && (unicode.IsLetter(rune(s[i])) || s[i] == '_') {
+		i++
+	}
+	if i == 0 || i == len(s) {
+		return "", "", s
+	}
+	for _, o := range []string{">=", "<=", ":", ">", "<", "="} {
+		if strings.HasPrefix(s[i:], o) {
+			return s[:i], o, s[i+len(o):]
+		}
+	}
+	return "", "", s
+}
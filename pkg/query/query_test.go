@@ -0,0 +1,124 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStructure(t *testing.T) {
+	tests := []struct {
+		q    string
+		want Expr
+	}{
+		{"", all{}},
+		{"   ", all{}},
+		{"bank", text("bank")},
+		{"bank konto", and{text("bank"), text("konto")}},
+		{"bank AND konto", and{text("bank"), text("konto")}},
+		// UND bindet stärker als ODER
+		{"a b OR c", or{and{text("a"), text("b")}, text("c")}},
+		{"a | b c", or{text("a"), and{text("b"), text("c")}}},
+		{"a (b OR c)", and{text("a"), or{text("b"), text("c")}}},
+		{"(a)", text("a")},
+		{"-a", not{text("a")}},
+		{"NOT a b", and{not{text("a")}, text("b")}},
+		{"-(a | b)", not{or{text("a"), text("b")}}},
+		{"- -a", and{text("-"), not{text("a")}}},
+		{"--a", not{not{text("a")}}},
+		{`"meine bank"`, text("meine bank")},
+		{`"(a | b)"`, text("(a | b)")},
+		{`a"b c"d`, text("ab cd")},
+		// eine nackte URL ist kein Feld "https"
+		{"https://example.com", text("https://example.com")},
+		{"2024-01-01", text("2024-01-01")},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.q)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.q, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, erwartet %#v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestSplitTerm(t *testing.T) {
+	tests := []struct {
+		in           string
+		key, op, val string
+	}{
+		{"vault:Privat", "vault", ":", "Privat"},
+		{"updated>=2024-01-01", "updated", ">=", "2024-01-01"},
+		{"created<2024-01-01", "created", "<", "2024-01-01"},
+		{"id=abc", "id", "=", "abc"},
+		{"user_name:x", "user_name", ":", "x"},
+		{"title:a:b", "title", ":", "a:b"},
+		{"bank", "", "", "bank"},
+		{":x", "", "", ":x"},
+		{"a1:x", "", "", "a1:x"},
+	}
+	for _, tt := range tests {
+		key, op, val := splitTerm(tt.in)
+		if key != tt.key || op != tt.op || val != tt.val {
+			t.Errorf("splitTerm(%q) = %q, %q, %q, erwartet %q, %q, %q", tt.in, key, op, val, tt.key, tt.op, tt.val)
+		}
+	}
+}
+
+func TestParseFieldNames(t *testing.T) {
+	for _, q := range []string{
+		"vault:x", "VAULT:x", "category:login", "cat:login", "tag:x", "title:x", "user:x", "username:x",
+		"url:x", "notes:x", "id:x", "has:totp", "is:favorite", "is:archiviert", "created:2024-01-01",
+		"updated>2024-01-01T10:00:00Z",
+	} {
+		if _, err := Parse(q); err != nil {
+			t.Errorf("Parse(%q): %v", q, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		q     string
+		pos   int
+		msg   string
+		caret string // Markierungszeile ohne die zwei Leerzeichen Einrückung
+	}{
+		{"(a b", 0, "Klammer nicht geschlossen", "^"},
+		{"a )", 2, "unerwartetes Token", "  ^"},
+		{"a OR", 4, "Begriff erwartet", "    ^"},
+		{"OR a", 0, "Begriff erwartet", "^^"},
+		{"a ()", 3, "Begriff erwartet", "   ^"},
+		{`title:"offen`, 0, "Anführungszeichen nicht geschlossen", "^^^^^^^^^^^^"},
+		{"vault:", 0, `Wert für "vault" fehlt`, "^^^^^^"},
+		{"farbe:rot", 0, `unbekanntes Feld "farbe"`, "^^^^^^^^^"},
+		{"a vault>x", 2, `"vault" kennt nur ":"`, "  ^^^^^^^"},
+		{"updated>gestern", 0, "Datum erwartet", "^^^^^^^^^^^^^^^"},
+		{"has:kaffee", 0, "unbekannter Wert has:kaffee", "^^^^^^^^^^"},
+		{"is:neu", 0, "unbekannter Wert is:neu", "^^^^^^"},
+		// Pos in Bytes, Markierung in Zeichen
+		{"tresör OR )", 11, "Begriff erwartet", "          ^"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.q)
+		var qe *Error
+		if !errors.As(err, &qe) {
+			t.Errorf("Parse(%q): %v, erwartet *Error", tt.q, err)
+			continue
+		}
+		if qe.Pos != tt.pos {
+			t.Errorf("Parse(%q): Position %d, erwartet %d", tt.q, qe.Pos, tt.pos)
+		}
+		if !strings.Contains(qe.Msg, tt.msg) {
+			t.Errorf("Parse(%q): Meldung %q, erwartet %q", tt.q, qe.Msg, tt.msg)
+		}
+		lines := strings.Split(err.Error(), "\n")
+		if len(lines) != 3 || lines[1] != "  "+tt.q || lines[2] != "  "+tt.caret {
+			t.Errorf("Parse(%q): Ausgabe\n%s\nerwartet Markierung %q", tt.q, err, tt.caret)
+		}
+	}
+}
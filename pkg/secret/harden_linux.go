@@ -0,0 +1,28 @@
+//go:build linux
+
+package secret
+
+import "golang.org/x/sys/unix"
+
+// Harden schaltet Core-Dumps ab (RLIMIT_CORE=0) und markiert den Prozess als nicht
+// dumpbar, damit weder ein Absturz noch ptrace durch andere Benutzer Geheimnisse offenlegt.
+func Harden() error {
+	if err := unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{Cur: 0, Max: 0}); err != nil {
+		return err
+	}
+	return unix.Prctl(unix.PR_SET_DUMPABLE, 0, 0, 0, 0)
+}
+
+// lock sperrt b gegen Auslagern in den Swap. Scheitert das (RLIMIT_MEMLOCK), bleibt
+// der Puffer ungesperrt; der Export soll daran nicht scheitern.
+func lock(b []byte) {
+	if len(b) > 0 {
+		_ = unix.Mlock(b)
+	}
+}
+
+func unlock(b []byte) {
+	if len(b) > 0 {
+		_ = unix.Munlock(b)
+	}
+}
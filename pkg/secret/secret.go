@@ -0,0 +1,53 @@
+// Package secret hält Passwörter und andere geheime Werte als Byte-Slices, die nach dem
+// Export überschrieben werden können. Go-Strings sind unveränderlich und bleiben bis zur
+// nächsten Garbage Collection (oft länger) im Speicher; Bytes lassen sich gezielt nullen.
+//
+// Kopien, die beim Rendern zwangsläufig als string entstehen (gofpdf arbeitet nur mit
+// Strings), sind kurzlebig, lassen sich aber nicht löschen. Deshalb verhindert Harden
+// zusätzlich, dass der Prozessspeicher in einem Core-Dump landet.
+package secret
+
+import "bytes"
+
+// Bytes ist ein geheimer Wert. Der Nullwert ist leer.
+type Bytes []byte
+
+// New kopiert s in einen neuen, nach Möglichkeit gegen Auslagern gesperrten Puffer.
+func New(s string) Bytes {
+	return FromBytes([]byte(s))
+}
+
+// FromBytes kopiert b in einen neuen Puffer wie New; b selbst bleibt unverändert.
+func FromBytes(b []byte) Bytes {
+	if len(b) == 0 {
+		return nil
+	}
+	out := make(Bytes, len(b))
+	copy(out, b)
+	lock(out)
+	return out
+}
+
+// Reveal liefert den Wert als string für Stellen, die keinen Byte-Slice annehmen.
+// Die Kopie lässt sich nicht überschreiben und sollte nicht aufbewahrt werden.
+func (b Bytes) Reveal() string {
+	return string(b)
+}
+
+// Empty meldet, ob der Wert leer ist oder nur aus Leerraum besteht.
+func (b Bytes) Empty() bool {
+	return len(bytes.TrimSpace(b)) == 0
+}
+
+// Wipe überschreibt den Wert mit Nullen und gibt die Speichersperre frei.
+func (b Bytes) Wipe() {
+	Zero(b)
+	unlock(b)
+}
+
+// Zero überschreibt einen beliebigen Puffer mit Nullen, z. B. das unverschlüsselte PDF.
+func Zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
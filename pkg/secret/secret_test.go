@@ -0,0 +1,93 @@
+package secret
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWipe(t *testing.T) {
+	tests := []struct {
+		name string
+		b    Bytes
+	}{
+		{"Passwort", New("korrekt pferd")},
+		{"kopiert", FromBytes([]byte{1, 2, 3, 0xff})},
+		{"lang", New(string(bytes.Repeat([]byte("x"), 1<<16)))},
+		{"leer", New("")},
+		{"nil", nil},
+	}
+	for _, tt := range tests {
+		n := len(tt.b)
+		tt.b.Wipe()
+		// Wipe überschreibt an Ort und Stelle, Länge und Puffer bleiben
+		if len(tt.b) != n || !bytes.Equal(tt.b, make([]byte, n)) {
+			t.Errorf("%s: nach Wipe %x", tt.name, tt.b)
+		}
+		tt.b.Wipe() // zweimal ist harmlos
+	}
+}
+
+func TestWipeSharesBuffer(t *testing.T) {
+	b := New("geheim")
+	alias := b[2:4] // z. B. ein Teilstück, das an anderer Stelle gehalten wird
+	b.Wipe()
+	if !bytes.Equal(alias, []byte{0, 0}) {
+		t.Errorf("Teilstück nach Wipe %q", alias)
+	}
+}
+
+func TestFromBytesCopies(t *testing.T) {
+	src := []byte("geheim")
+	b := FromBytes(src)
+	src[0] = 'X'
+	if b.Reveal() != "geheim" {
+		t.Errorf("FromBytes teilt den Puffer: %q", b)
+	}
+	b.Wipe()
+	if string(src) != "Xeheim" {
+		t.Errorf("Wipe hat die Quelle verändert: %q", src)
+	}
+	if FromBytes(nil) != nil || FromBytes([]byte{}) != nil {
+		t.Error("FromBytes leer liefert nicht nil")
+	}
+}
+
+func TestZero(t *testing.T) {
+	tests := []struct {
+		in       []byte
+		from, to int // genullter Bereich
+	}{
+		{[]byte("abcdef"), 0, 6},
+		{[]byte("abcdef"), 2, 4},
+		{[]byte("abcdef"), 3, 3},
+		{nil, 0, 0},
+	}
+	for _, tt := range tests {
+		want := append([]byte(nil), tt.in...)
+		for i := tt.from; i < tt.to; i++ {
+			want[i] = 0
+		}
+		Zero(tt.in[tt.from:tt.to])
+		if !bytes.Equal(tt.in, want) {
+			t.Errorf("Zero[%d:%d] = %q, erwartet %q", tt.from, tt.to, tt.in, want)
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	tests := []struct {
+		b    Bytes
+		want bool
+	}{
+		{nil, true},
+		{New(""), true},
+		{New(" \t\n"), true},
+		{New(" x "), false},
+		{Bytes{0}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.b.Empty(); got != tt.want {
+			t.Errorf("Bytes(%q).Empty() = %v", tt.b, got)
+		}
+	}
+}
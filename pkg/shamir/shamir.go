@@ -0,0 +1,177 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Share ist ein Anteil eines mit Split geteilten Geheimnisses.
+type Share struct {
+	Set       string // zufällige Kennung, damit Anteile verschiedener Exporte nicht gemischt werden
+	Threshold int    // Anzahl benötigter Anteile
+	X         byte   // Stützstelle (1..255)
+	Y         []byte // ein Funktionswert je Byte des Geheimnisses
+}
+
+// CheckSplit prüft, ob sich ein Geheimnis in n Anteile mit Schwelle k teilen lässt.
+func CheckSplit(k, n int) error {
+	if k < 2 || n < k || n > 255 {
+		return fmt.Errorf("shamir: ungültige Aufteilung %d von %d (2 ≤ k ≤ n ≤ 255)", k, n)
+	}
+	return nil
+}
+
+// Split teilt secret in n Anteile, von denen beliebige k das Geheimnis rekonstruieren.
+// Jedes Byte wird unabhängig über ein zufälliges Polynom vom Grad k-1 in GF(256) geteilt;
+// weniger als k Anteile verraten nichts über das Geheimnis.
+func Split(secret []byte, n, k int) ([]Share, error) {
+	if err := CheckSplit(k, n); err != nil {
+		return nil, err
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("shamir: leeres Geheimnis")
+	}
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{Set: hex.EncodeToString(id), Threshold: k, X: byte(i + 1), Y: make([]byte, len(secret))}
+	}
+	// coeff[0] ist das jeweilige Byte des Geheimnisses
+	coeff := make([]byte, k)
+	defer clear(coeff)
+	for b, s := range secret {
+		coeff[0] = s
+		if _, err := rand.Read(coeff[1:]); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			shares[i].Y[b] = evaluate(coeff, shares[i].X)
+		}
+	}
+	return shares, nil
+}
+
+// Combine rekonstruiert das Geheimnis per Lagrange-Interpolation an der Stelle 0.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("shamir: keine Anteile")
+	}
+	first := shares[0]
+	if len(shares) < first.Threshold {
+		return nil, fmt.Errorf("shamir: %d von %d benötigten Anteilen", len(shares), first.Threshold)
+	}
+	seen := map[byte]bool{}
+	for _, s := range shares {
+		if s.Set != first.Set || s.Threshold != first.Threshold || len(s.Y) != len(first.Y) {
+			return nil, errors.New("shamir: Anteile gehören nicht zum selben Export")
+		}
+		if seen[s.X] {
+			return nil, fmt.Errorf("shamir: Anteil %d doppelt angegeben", s.X)
+		}
+		seen[s.X] = true
+	}
+	shares = shares[:first.Threshold]
+
+	secret := make([]byte, len(first.Y))
+	for b := range secret {
+		var v byte
+		for i, si := range shares {
+			// Lagrange-Basis l_i(0) = Π x_j / (x_j - x_i); Subtraktion ist in GF(256) XOR
+			num, den := byte(1), byte(1)
+			for j, sj := range shares {
+				if i == j {
+					continue
+				}
+				num = mul(num, sj.X)
+				den = mul(den, sj.X^si.X)
+			}
+			v ^= mul(si.Y[b], div(num, den))
+		}
+		secret[b] = v
+	}
+	return secret, nil
+}
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// String kodiert einen Anteil zum Abtippen oder als QR-Code:
+// OPW1-<Set>-<k>-<x>-<Y in Base32>-<Prüfsumme>.
+func (s Share) String() string {
+	body := fmt.Sprintf("OPW1-%s-%d-%d-%s", strings.ToUpper(s.Set), s.Threshold, s.X, b32.EncodeToString(s.Y))
+	return body + "-" + checksum(body)
+}
+
+// Parse liest einen mit String kodierten Anteil. Leerzeichen und Groß-/Kleinschreibung
+// werden ignoriert; Tippfehler erkennt die Prüfsumme.
+func Parse(str string) (Share, error) {
+	str = strings.ToUpper(strings.Join(strings.Fields(str), ""))
+	parts := strings.Split(str, "-")
+	if len(parts) != 6 || parts[0] != "OPW1" {
+		return Share{}, errors.New("shamir: kein gültiger Anteil (erwartet OPW1-…)")
+	}
+	if checksum(strings.Join(parts[:5], "-")) != parts[5] {
+		return Share{}, errors.New("shamir: Prüfsumme falsch – Tippfehler im Anteil?")
+	}
+	k, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return Share{}, errors.New("shamir: ungültige Schwelle")
+	}
+	x, err := strconv.Atoi(parts[3])
+	if err != nil || x < 1 || x > 255 {
+		return Share{}, errors.New("shamir: ungültige Anteilsnummer")
+	}
+	y, err := b32.DecodeString(parts[4])
+	if err != nil {
+		return Share{}, errors.New("shamir: ungültige Daten im Anteil")
+	}
+	return Share{Set: strings.ToLower(parts[1]), Threshold: k, X: byte(x), Y: y}, nil
+}
+
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(s)))
+	return strings.ToUpper(hex.EncodeToString(sum[:2]))
+}
+
+// evaluate wertet das Polynom mit den Koeffizienten coeff (aufsteigend) an x aus (Horner).
+func evaluate(coeff []byte, x byte) byte {
+	var y byte
+	for i := len(coeff) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coeff[i]
+	}
+	return y
+}
+
+// mul multipliziert in GF(256) mit dem AES-Polynom x^8 + x^4 + x^3 + x + 1.
+func mul(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// div teilt in GF(256); das Inverse ist a^254.
+func div(a, b byte) byte {
+	inv := byte(1)
+	for i := 0; i < 254; i++ {
+		inv = mul(inv, b)
+	}
+	return mul(a, inv)
+}
@@ -0,0 +1,198 @@
+package shamir
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var testSecret = []byte("korrekt-pferd-batterie-heftklammer-2024")
+
+// subsets ruft fn mit jeder k-elementigen Teilmenge von shares auf.
+func subsets(shares []Share, k int, fn func([]Share)) {
+	var rec func(start int, cur []Share)
+	rec = func(start int, cur []Share) {
+		if len(cur) == k {
+			fn(append([]Share(nil), cur...))
+			return
+		}
+		for i := start; i < len(shares); i++ {
+			rec(i+1, append(cur, shares[i]))
+		}
+	}
+	rec(0, nil)
+}
+
+func TestSplitCombine(t *testing.T) {
+	tests := []struct{ k, n int }{
+		{2, 2}, {2, 3}, {3, 5}, {4, 6}, {5, 5}, {3, 255},
+	}
+	for _, tt := range tests {
+		shares, err := Split(testSecret, tt.n, tt.k)
+		if err != nil {
+			t.Fatalf("%d/%d: %v", tt.k, tt.n, err)
+		}
+		if len(shares) != tt.n {
+			t.Fatalf("%d/%d: %d Anteile", tt.k, tt.n, len(shares))
+		}
+		if tt.n > 10 {
+			// bei 255 Anteilen nur einige Auswahlen statt aller Teilmengen
+			for _, pick := range [][]Share{shares[:tt.k], shares[tt.n-tt.k:], {shares[200], shares[7], shares[254]}} {
+				if got, err := Combine(pick); err != nil || !bytes.Equal(got, testSecret) {
+					t.Errorf("%d/%d: Combine = %q, %v", tt.k, tt.n, got, err)
+				}
+			}
+			continue
+		}
+		subsets(shares, tt.k, func(pick []Share) {
+			if got, err := Combine(pick); err != nil || !bytes.Equal(got, testSecret) {
+				t.Errorf("%d/%d mit %v: Combine = %q, %v", tt.k, tt.n, xs(pick), got, err)
+			}
+		})
+		// mehr als k Anteile gehen auch
+		if got, err := Combine(shares); err != nil || !bytes.Equal(got, testSecret) {
+			t.Errorf("%d/%d mit allen: Combine = %q, %v", tt.k, tt.n, got, err)
+		}
+	}
+}
+
+func xs(shares []Share) []byte {
+	var out []byte
+	for _, s := range shares {
+		out = append(out, s.X)
+	}
+	return out
+}
+
+func TestTooFewShares(t *testing.T) {
+	for _, tt := range []struct{ k, n int }{{2, 3}, {3, 5}, {5, 6}} {
+		shares, err := Split(testSecret, tt.n, tt.k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subsets(shares, tt.k-1, func(pick []Share) {
+			if _, err := Combine(pick); err == nil {
+				t.Errorf("%d/%d: Combine mit %v ohne Fehler", tt.k, tt.n, xs(pick))
+			}
+			// auch bei umgangener Prüfung ergeben k-1 Anteile nicht das Geheimnis
+			for i := range pick {
+				pick[i].Threshold = tt.k - 1
+			}
+			if tt.k-1 < 2 {
+				return
+			}
+			if got, err := Combine(pick); err != nil || bytes.Equal(got, testSecret) {
+				t.Errorf("%d/%d: %d Anteile %v ergeben %q, %v", tt.k, tt.n, tt.k-1, xs(pick), got, err)
+			}
+		})
+	}
+}
+
+func TestCombineRejects(t *testing.T) {
+	a, err := Split(testSecret, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Split(testSecret, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	short := a[1]
+	short.Y = short.Y[:len(short.Y)-1]
+	other := a[1]
+	other.Threshold = 3
+	tests := []struct {
+		name   string
+		shares []Share
+		msg    string
+	}{
+		{"keine", nil, "keine Anteile"},
+		{"zu wenige", a[:1], "1 von 2"},
+		{"doppelt", []Share{a[0], a[0]}, "doppelt"},
+		{"anderer Export", []Share{a[0], b[1]}, "nicht zum selben Export"},
+		{"andere Schwelle", []Share{a[0], other}, "nicht zum selben Export"},
+		{"andere Länge", []Share{a[0], short}, "nicht zum selben Export"},
+	}
+	for _, tt := range tests {
+		if _, err := Combine(tt.shares); err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%s: %v, erwartet %q", tt.name, err, tt.msg)
+		}
+	}
+}
+
+func TestSplitRejects(t *testing.T) {
+	tests := []struct {
+		secret []byte
+		k, n   int
+	}{
+		{testSecret, 1, 3}, {testSecret, 4, 3}, {testSecret, 2, 256}, {testSecret, 0, 0}, {nil, 2, 3},
+	}
+	for _, tt := range tests {
+		if _, err := Split(tt.secret, tt.n, tt.k); err == nil {
+			t.Errorf("Split(%q, %d, %d) ohne Fehler", tt.secret, tt.n, tt.k)
+		}
+	}
+}
+
+func TestStringParse(t *testing.T) {
+	shares, err := Split(testSecret, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range shares {
+		str := s.String()
+		// abgetippt: klein, mit Leerzeichen und Zeilenumbruch
+		typed := strings.ToLower(str[:10]) + " " + str[10:20] + "\n" + str[20:]
+		for _, in := range []string{str, typed} {
+			got, err := Parse(in)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", in, err)
+			}
+			if got.Set != s.Set || got.Threshold != s.Threshold || got.X != s.X || !bytes.Equal(got.Y, s.Y) {
+				t.Errorf("Parse(%q) = %+v, erwartet %+v", in, got, s)
+			}
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	// body mit passender Prüfsumme, damit die Felder selbst geprüft werden
+	signed := func(body string) string { return body + "-" + checksum(body) }
+	shares, err := Split(testSecret, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := shares[0].String()
+	typo := []byte(valid)
+	typo[len("OPW1-12345678-2-1-")] ^= 1
+	tests := []struct {
+		in  string
+		msg string
+	}{
+		{"", "kein gültiger Anteil"},
+		{"hallo welt", "kein gültiger Anteil"},
+		{strings.Replace(valid, "OPW1", "OPW2", 1), "kein gültiger Anteil"},
+		{valid + "-AB", "kein gültiger Anteil"},
+		{string(typo), "Prüfsumme"},
+		{signed("OPW1-12345678-X-1-MFRGG"), "Schwelle"},
+		{signed("OPW1-12345678-2-0-MFRGG"), "Anteilsnummer"},
+		{signed("OPW1-12345678-2-256-MFRGG"), "Anteilsnummer"},
+		{signed("OPW1-12345678-2-1-MFRG1"), "ungültige Daten"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.in); err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("Parse(%q): %v, erwartet %q", tt.in, err, tt.msg)
+		}
+	}
+}
+
+func TestField(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		if got := mul(byte(a), div(1, byte(a))); got != 1 {
+			t.Fatalf("%d · %d⁻¹ = %d", a, a, got)
+		}
+	}
+	if mul(0x57, 0x83) != 0xc1 { // Beispiel aus FIPS 197
+		t.Errorf("0x57 · 0x83 = %#x", mul(0x57, 0x83))
+	}
+}
@@ -0,0 +1,121 @@
+// Package snapshot speichert die Items eines Exports verschlüsselt als JSON, damit ein
+// späterer Export mit diff dagegen verglichen werden kann. Der Schlüssel wird mit Argon2id
+// aus dem PDF-Passwort abgeleitet, verschlüsselt wird mit AES-256-GCM.
+package snapshot
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/pwhash"
+	"github.com/example/onepw-pdf-export/pkg/secret"
+)
+
+// Version kennzeichnet das Dateiformat.
+const Version = 1
+
+// ErrWrongPassword meldet ein falsches Passwort oder eine veränderte Datei;
+// GCM kann beides nicht unterscheiden.
+var ErrWrongPassword = errors.New("snapshot: falsches Passwort oder Datei beschädigt")
+
+// aad bindet den Chiffretext an Format und Version.
+var aad = []byte("onepw-pdf-export snapshot v1")
+
+// Snapshot ist der entschlüsselte Inhalt.
+type Snapshot struct {
+	Created time.Time    `json:"created"`
+	Source  string       `json:"source"` // csv | op | 1pux
+	Items   []model.Item `json:"items"`
+}
+
+// envelope ist das, was auf der Platte liegt.
+type envelope struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	pwhash.Params
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// Write verschlüsselt s mit password und legt die Datei mit Modus 0600 an.
+func Write(path string, s Snapshot, password []byte) error {
+	if len(password) == 0 {
+		return errors.New("snapshot: Passwort ist leer")
+	}
+	params, err := pwhash.NewParams()
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	defer secret.Zero(plain)
+
+	gcm, err := newGCM(params, password)
+	if err != nil {
+		return err
+	}
+	env := envelope{Version: Version, KDF: "argon2id", Params: params, Nonce: make([]byte, gcm.NonceSize())}
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return err
+	}
+	env.Data = gcm.Seal(nil, env.Nonce, plain, aad)
+	out, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	return os.WriteFile(path, out, 0o600)
+}
+
+// Read entschlüsselt einen mit Write erzeugten Snapshot.
+func Read(path string, password []byte) (Snapshot, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return Snapshot{}, fmt.Errorf("snapshot: %s ist kein Snapshot: %w", path, err)
+	}
+	if env.Version != Version || env.KDF != "argon2id" {
+		return Snapshot{}, fmt.Errorf("snapshot: Version %d (%s) wird nicht unterstützt", env.Version, env.KDF)
+	}
+	if err := env.Params.Check(); err != nil {
+		return Snapshot{}, fmt.Errorf("snapshot: %s: %w", path, err)
+	}
+	gcm, err := newGCM(env.Params, password)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if len(env.Nonce) != gcm.NonceSize() {
+		return Snapshot{}, ErrWrongPassword
+	}
+	plain, err := gcm.Open(nil, env.Nonce, env.Data, aad)
+	if err != nil {
+		return Snapshot{}, ErrWrongPassword
+	}
+	defer secret.Zero(plain)
+	var s Snapshot
+	if err := json.Unmarshal(plain, &s); err != nil {
+		return Snapshot{}, fmt.Errorf("snapshot: %w", err)
+	}
+	return s, nil
+}
+
+func newGCM(p pwhash.Params, password []byte) (cipher.AEAD, error) {
+	key := p.Key(password, 32)
+	defer secret.Zero(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
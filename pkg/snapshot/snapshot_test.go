@@ -0,0 +1,140 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/onepw-pdf-export/pkg/model"
+	"github.com/example/onepw-pdf-export/pkg/secret"
+)
+
+const pwText = "richtiges passwort"
+
+var password = []byte(pwText)
+
+func testSnapshot() Snapshot {
+	return Snapshot{
+		Created: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Source:  "op",
+		Items: []model.Item{
+			{ID: "b1", Title: "Bank", Vault: "Privat", Username: "anna", Password: secret.New("streng-geheim-123"),
+				RawFields: map[string]secret.Bytes{"PIN": secret.New("4711")}, Concealed: map[string]bool{"PIN": true}},
+			{ID: "m1", Title: "Mail", Vault: "Arbeit", Tags: []string{"alt"}},
+		},
+	}
+}
+
+// writeTest schreibt den Test-Snapshot und liefert Pfad und Dateiinhalt.
+func writeTest(t *testing.T) (string, []byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stand.snap")
+	if err := Write(path, testSnapshot(), password); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, raw
+}
+
+func TestRoundTrip(t *testing.T) {
+	path, raw := writeTest(t)
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("Dateimodus %v, %v, erwartet 0600", fi.Mode().Perm(), err)
+	}
+	for _, plain := range []string{"streng-geheim-123", "4711", "anna", "Bank"} {
+		if bytes.Contains(raw, []byte(plain)) {
+			t.Errorf("%q im Klartext in der Datei", plain)
+		}
+	}
+	got, err := Read(path, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := testSnapshot()
+	if !got.Created.Equal(want.Created) || got.Source != want.Source || len(got.Items) != len(want.Items) {
+		t.Fatalf("Read = %+v, erwartet %+v", got, want)
+	}
+	b := got.Items[0]
+	if b.Title != "Bank" || string(b.Password) != "streng-geheim-123" || string(b.RawFields["PIN"]) != "4711" || !b.Concealed["PIN"] {
+		t.Errorf("Item %+v", b)
+	}
+	if got.Items[1].Tags[0] != "alt" {
+		t.Errorf("Tags %v", got.Items[1].Tags)
+	}
+
+	// jeder Snapshot hat eigenes Salz und eigene Nonce
+	_, raw2 := writeTest(t)
+	var e1, e2 envelope
+	if json.Unmarshal(raw, &e1) != nil || json.Unmarshal(raw2, &e2) != nil {
+		t.Fatal("Datei ist kein JSON")
+	}
+	if bytes.Equal(e1.Salt, e2.Salt) || bytes.Equal(e1.Nonce, e2.Nonce) {
+		t.Error("Salz oder Nonce wiederverwendet")
+	}
+}
+
+func TestWriteRejectsEmptyPassword(t *testing.T) {
+	if err := Write(filepath.Join(t.TempDir(), "x.snap"), testSnapshot(), nil); err == nil {
+		t.Error("leeres Passwort akzeptiert")
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		edit     func(*envelope)
+		wrongPW  bool   // ErrWrongPassword erwartet
+		msg      string // sonst Teil der Meldung
+	}{
+		{"falsches Passwort", "falsches passwort", nil, true, ""},
+		{"leeres Passwort", "", nil, true, ""},
+		{"Chiffretext verändert", pwText, func(e *envelope) { e.Data[len(e.Data)/2] ^= 1 }, true, ""},
+		{"Tag verändert", pwText, func(e *envelope) { e.Data[len(e.Data)-1] ^= 0x80 }, true, ""},
+		{"gekürzt", pwText, func(e *envelope) { e.Data = e.Data[:len(e.Data)-1] }, true, ""},
+		{"Nonce verändert", pwText, func(e *envelope) { e.Nonce[0] ^= 1 }, true, ""},
+		{"Nonce zu kurz", pwText, func(e *envelope) { e.Nonce = e.Nonce[:8] }, true, ""},
+		{"Salz verändert", pwText, func(e *envelope) { e.Salt[0] ^= 1 }, true, ""},
+		{"Version", pwText, func(e *envelope) { e.Version = 2 }, false, "Version 2"},
+		{"KDF", pwText, func(e *envelope) { e.KDF = "scrypt" }, false, "scrypt"},
+		{"Threads 0", pwText, func(e *envelope) { e.Threads = 0 }, false, "Threads"},
+		{"Speicher", pwText, func(e *envelope) { e.Memory = 1 << 30 }, false, "Speicher"},
+	}
+	for _, tt := range tests {
+		path, raw := writeTest(t)
+		if tt.edit != nil {
+			var env envelope
+			if err := json.Unmarshal(raw, &env); err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(&env)
+			out, _ := json.Marshal(env)
+			if err := os.WriteFile(path, out, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := Read(path, []byte(tt.password))
+		switch {
+		case tt.wrongPW && !errors.Is(err, ErrWrongPassword):
+			t.Errorf("%s: %v, erwartet ErrWrongPassword", tt.name, err)
+		case !tt.wrongPW && (err == nil || !strings.Contains(err.Error(), tt.msg)):
+			t.Errorf("%s: %v, erwartet %q", tt.name, err, tt.msg)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "kaputt.snap")
+	if err := os.WriteFile(path, []byte("kein json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path, password); err == nil || !strings.Contains(err.Error(), "kein Snapshot") {
+		t.Errorf("kein JSON: %v", err)
+	}
+}